@@ -1,13 +1,15 @@
 package buffer_test
 
 import (
+	"fmt"
+	"sync/atomic"
 	"testing"
 
 	"github.com/omniboost/go-buffer"
 )
 
 func BenchmarkBuffer(b *testing.B) {
-	noop := buffer.FlusherFunc[any](func([]any) {})
+	noop := buffer.FlusherFunc[any](func([]any) error { return nil })
 
 	b.Run("push only", func(b *testing.B) {
 		sut := buffer.New[any]().
@@ -41,3 +43,164 @@ func BenchmarkBuffer(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkStats(b *testing.B) {
+	noop := buffer.FlusherFunc[any](func([]any) error { return nil })
+
+	sut := buffer.New[any]().
+		WithSize(10).
+		WithFlusher(noop)
+
+	defer sut.Close()
+
+	if err := sut.Push(0); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("snapshot from consume goroutine", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := sut.Stats(); err != nil {
+				b.Fail()
+			}
+		}
+	})
+
+	b.Run("snapshot from atomic counters", func(b *testing.B) {
+		var counters atomicStats
+		counters.push()
+
+		for i := 0; i < b.N; i++ {
+			statsSink = counters.snapshot()
+		}
+	})
+}
+
+// BenchmarkStatsUpdate compares the cost the consume goroutine pays for every
+// push to keep its counters, which it owns, with the cost of atomic counters
+// that other goroutines could read without asking it.
+func BenchmarkStatsUpdate(b *testing.B) {
+	b.Run("owned counters", func(b *testing.B) {
+		var counters buffer.Stats
+		for i := 0; i < b.N; i++ {
+			counters.Pushed++
+			counters.Pending++
+		}
+		statsSink = counters
+	})
+
+	b.Run("atomic counters", func(b *testing.B) {
+		var counters atomicStats
+		for i := 0; i < b.N; i++ {
+			counters.push()
+		}
+		statsSink = counters.snapshot()
+	})
+
+	b.Run("atomic counters read concurrently", func(b *testing.B) {
+		var counters atomicStats
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					_ = counters.snapshot()
+				}
+			}
+		}()
+
+		for i := 0; i < b.N; i++ {
+			counters.push()
+		}
+	})
+}
+
+var statsSink buffer.Stats
+
+// atomicStats keeps the counters of Stats in atomics, the alternative to
+// having the consume goroutine serve snapshots.
+type atomicStats struct {
+	pending atomic.Int64
+	pushed  atomic.Uint64
+	flushes atomic.Uint64
+	flushed atomic.Uint64
+	errors  atomic.Uint64
+}
+
+func (s *atomicStats) push() {
+	s.pushed.Add(1)
+	s.pending.Add(1)
+}
+
+func (s *atomicStats) snapshot() buffer.Stats {
+	return buffer.Stats{
+		Pending: int(s.pending.Load()),
+		Pushed:  s.pushed.Load(),
+		Flushes: s.flushes.Load(),
+		Flushed: s.flushed.Load(),
+		Errors:  s.errors.Load(),
+	}
+}
+
+func BenchmarkPush(b *testing.B) {
+	noop := buffer.FlusherFunc[any](func([]any) error { return nil })
+
+	b.Run("ready consumer", func(b *testing.B) {
+		sut := buffer.New[any]().
+			WithSize(1024).
+			WithFlusher(noop)
+
+		defer sut.Close()
+
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := sut.Push(i); err != nil {
+				b.Fail()
+			}
+		}
+	})
+
+	b.Run("contended", func(b *testing.B) {
+		sut := buffer.New[any]().
+			WithSize(1024).
+			WithFlusher(noop)
+
+		if err := sut.Push(0); err != nil {
+			b.Fatal(err)
+		}
+		defer sut.Close()
+
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if err := sut.Push(0); err != nil {
+					b.Fail()
+				}
+			}
+		})
+	})
+}
+
+func BenchmarkSynchronous(b *testing.B) {
+	noop := buffer.FlusherFunc[any](func([]any) error { return nil })
+
+	for _, size := range []uint{1, 16, 1024} {
+		b.Run(fmt.Sprintf("size %d", size), func(b *testing.B) {
+			sut := buffer.New[any]().
+				WithSize(size).
+				WithFlusher(noop).
+				WithSynchronousMode()
+
+			defer sut.Close()
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := sut.Push(i); err != nil {
+					b.Fail()
+				}
+			}
+		})
+	}
+}
@@ -1,8 +1,14 @@
 package buffer
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"io"
+	"math/rand"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -11,107 +17,867 @@ var (
 	ErrTimeout = errors.New("operation timed-out")
 	// ErrClosed indicates the buffer is closed and can no longer be used.
 	ErrClosed = errors.New("buffer is closed")
+	// ErrNotInitialized indicates the buffer has not been initialized yet, which
+	// happens on the first Push.
+	ErrNotInitialized = errors.New("buffer is not initialized")
+	// ErrInvalidBatch indicates a batch was rejected by the batch validator.
+	ErrInvalidBatch = errors.New("batch is invalid")
+	// ErrZeroValue indicates a zero value was pushed while those are rejected.
+	ErrZeroValue = errors.New("item is a zero value")
+	// ErrInvalidItem indicates an item was rejected by the item validator.
+	ErrInvalidItem = errors.New("item is invalid")
+	// ErrBufferFull indicates a push timed out because the consume goroutine did
+	// not accept the item, while it was running and not flushing.
+	ErrBufferFull = errors.New("buffer is full")
+	// ErrFlushBlocked indicates a push timed out because the consume goroutine
+	// was busy writing a batch, or waiting for a batch in flight to be written.
+	ErrFlushBlocked = errors.New("consume goroutine is blocked on a flush")
+	// ErrEvicted indicates an item was evicted to stay within the memory limit.
+	ErrEvicted = errors.New("item was evicted")
+	// ErrNotStarted indicates an item was pushed before the buffer was started,
+	// while it requires an explicit start.
+	ErrNotStarted = errors.New("buffer is not started")
+	// ErrNoFlusher indicates the flusher selector did not select a flusher for a
+	// batch, or a type routing flusher has no flusher for some of its items.
+	ErrNoFlusher = errors.New("no flusher selected for batch")
+	// ErrFlusherPanicked indicates the flusher panicked while writing a batch,
+	// and the panic was recovered by the panic handler.
+	ErrFlusherPanicked = errors.New("flusher panicked")
+	// ErrDataLoss indicates Close timed out while items were still unwritten,
+	// see Lost.
+	ErrDataLoss = errors.New("items were not written")
+	// ErrPartialWrite indicates a partial flusher reported failed items without
+	// an error.
+	ErrPartialWrite = errors.New("some items were not written")
+)
+
+const (
+	// MemoryFlush flushes the buffer early when a push would exceed the memory
+	// limit.
+	MemoryFlush MemoryPolicy = iota
+	// MemoryEvict evicts the oldest items when a push would exceed the memory
+	// limit.
+	MemoryEvict
+)
+
+const (
+	// ReturnError makes a push to a closed buffer fail with an ErrClosed.
+	ReturnError ClosedPushBehavior = iota
+	// SilentlyDrop makes a push to a closed buffer succeed, discarding the item.
+	SilentlyDrop
+)
+
+const (
+	// FIFO hands every batch to the flusher in push order.
+	FIFO FlushOrder = iota
+	// LIFO hands every batch to the flusher in reverse push order, most recent
+	// item first.
+	LIFO
 )
 
 type (
+	// FlushOrder determines the order of the items within a batch handed to the
+	// flusher.
+	FlushOrder int
+
+	// ClosedPushBehavior determines what happens to an item pushed to a closed
+	// buffer.
+	ClosedPushBehavior int
+
+	// MemoryPolicy determines how the buffer makes room when a push would exceed
+	// the memory limit.
+	MemoryPolicy int
+
+	// pendingFlush is the outcome of a Flush shared with the calls that
+	// coalesced into it, available once done is closed.
+	pendingFlush struct {
+		done chan struct{}
+		err  error
+	}
+
 	// Buffer represents a data buffer that is asynchronously flushed, either manually or automatically.
+	//
+	// Items are flushed in the exact order they were pushed: concatenating every
+	// batch handed to the Flusher yields the push order, regardless of whether a
+	// flush was triggered by size, interval, Flush or Close. This only holds as
+	// long as flushes do not overlap, see WithOverlappingFlush, and the flush
+	// order is FIFO, see WithFlushOrder.
 	Buffer[T any] struct {
 		io.Closer
-		dataCh  chan T
-		flushCh chan struct{}
-		closeCh chan struct{}
-		doneCh  chan struct{}
+		dataCh     chan entry[T]
+		priorityCh chan entry[T]
+		flushCh    chan flushRequest[T]
+		closeCh    chan struct{}
+		doneCh     chan struct{}
+		statsCh    chan statsRequest
+		resultCh   chan flushResult[T]
+		pending    atomic.Int64
+		// flushPending is set while a Flush is waiting for the consume
+		// goroutine to take its request, so that concurrent calls coalesce
+		// into it.
+		flushPending *pendingFlush
+		flushMu      sync.Mutex
+		// flushing is set while the consume goroutine writes a batch inline, or
+		// waits for a batch in flight.
+		flushing atomic.Bool
+		// released is set by the Close call that saw the buffer closed.
+		released atomic.Bool
+		// lost is the number of unwritten items when Close last timed out.
+		lost atomic.Int64
+
+		subscribers subscribers
+		pressure    pressure
+		timeouts    timeouts
+		discarded   []T
+		flushDone   flushDone
+		health      health
+		watchdog    watchdog
+		emptied     emptied
+		retryRand   lockedRand
+		errCh       errorChannel
+		// direct is the consumer of a buffer in synchronous mode.
+		direct    *consumer[T]
+		directMu  sync.Mutex
+		writeMu   sync.Mutex
+		closeOnce sync.Once
+		closeErr  error
+		// sendMu is held to send an item to the consume goroutine, and taken
+		// exclusively once the buffer is closing, so that no item lands in
+		// the channel buffer after the final flush picked it up.
+		sendMu sync.RWMutex
 
 		// options
-		Size          uint
-		Flusher       Flusher[T]
-		FlushInterval time.Duration
-		PushTimeout   time.Duration
-		FlushTimeout  time.Duration
-		CloseTimeout  time.Duration
+		Size                uint
+		Flusher             Flusher[T]
+		ContextFlusher      ContextFlusher[T]
+		FlusherSelector     func(items []T) Flusher[T]
+		FlushInterval       time.Duration
+		PushTimeout         time.Duration
+		FlushTimeout        time.Duration
+		PerItemFlushTimeout time.Duration
+		CloseTimeout        time.Duration
+		CopyOnFlush         bool
+		HighWaterMark       uint
+		LowWaterMark        uint
+		BatchValidator      func(items []T) error
+		ErrorHandler        func(err error, items []T)
+		Retries             uint
+		RetryBackoff        time.Duration
+		DeadLetter          Flusher[T]
+		OverlappingFlush    bool
+		OnClose             func()
+		Metrics             Metrics
+		IsZero              func(item T) bool
+		FlushLaneKey        func(items []T) any
+		DiscardOnClose      bool
+		FlushDoneSignal     bool
+		MemoryLimit         int
+		SizeOf              func(item T) int
+		OnEvict             func(item T)
+		MemoryPolicy        MemoryPolicy
+		InitialDelay        time.Duration
+		RequeueAttempts     int
+		RequireStart        bool
+		SampleRate          float64
+		RandSource          rand.Source
+		HealthFailures      uint
+		HealthWindow        time.Duration
+		FlushGroupKey       func(item T) any
+		BatchTransform      func(items []T) []T
+		ExclusiveFlush      bool
+		DedupKey            func(item T) any
+		DedupWindow         time.Duration
+		ClosedPushBehavior  ClosedPushBehavior
+		StableInterval      bool
+		TimeoutError        func(op string) error
+		MaxWriteBatch       int
+		MaxInFlightBatches  int
+		PanicHandler        func(recovered any, stack []byte)
+		TimestampedFlusher  TimestampedFlusher[T]
+		FlushDebounce       time.Duration
+		PartialFlusher      PartialFlusher[T]
+		NoFlushOnFull       bool
+		FlushTrigger        <-chan struct{}
+		FlushOrder          FlushOrder
+		CloseFlusher        bool
+		Watchdog            time.Duration
+		OnStuck             func()
+		LastValueKey        func(item T) any
+		PushHook            func(item T, accepted bool)
+		RetryJitter         float64
+		RetryMaxElapsed     time.Duration
+		RetryRandSource     rand.Source
+		ItemValidator       func(item T) error
+		ChannelBuffer       int
+		StrictSize          bool
+		ErrChSize           int
+		Synchronous         bool
+		OnEmptyFlush        func(reason FlushReason)
+		SkipCancelled       bool
+		SizeSchedule        func(now time.Time) uint
+		Clock               func() time.Time
+		AfterFlush          func(count int)
+		PushRetryAttempts   int
+		PushRetryBackoff    time.Duration
+		DistinctKey         func(item T) any
+		DistinctKeys        int
+	}
+
+	// entry is an item on its way to the consume goroutine.
+	entry[T any] struct {
+		item T
+		ack  func(err error)
+		// ctx is the context the item was pushed with, if any.
+		ctx context.Context
+		// flushed receives whether accepting the item triggered a flush.
+		flushed chan bool
+	}
+
+	flushRequest[T any] struct {
+		// partial restricts the flush to items older than olderThan.
+		partial   bool
+		olderThan time.Duration
+		// reply receives the outcome of the flush, including a copy of the
+		// flushed items when collect is set.
+		reply   chan flushReply[T]
+		collect bool
+		// ctx is handed to a context-aware flusher, defaulting to the
+		// background context.
+		ctx    context.Context
+		reason FlushReason
+		// drain hands the buffered items to the reply channel rather than
+		// to the flusher.
+		drain bool
+		// snapshot hands a copy of the buffered items to the reply channel,
+		// keeping them buffered.
+		snapshot bool
+		// barrier is run once the flush and every flush in flight completed.
+		barrier func() error
+		// pending is the Flush the request was made by, which stops taking
+		// in other calls once the consume goroutine has taken the request.
+		pending *pendingFlush
+	}
+
+	flushReply[T any] struct {
+		items []T
+		err   error
+	}
+
+	// flushResult is the outcome of a flush, along with the batch and its
+	// bookkeeping when the batch should be requeued.
+	flushResult[T any] struct {
+		err      error
+		items    []T
+		stamps   []time.Time
+		attempts []int
+		acks     []func(err error)
+		ctxs     []context.Context
 	}
 )
 
+// Start validates the options and initializes the buffer, starting the consume
+// goroutine right away rather than on the first Push. It does nothing if the
+// buffer is already started.
+//
+// It returns the validation error if the options are invalid, and an ErrClosed
+// if the buffer has been closed.
+func (buffer *Buffer[T]) Start() error {
+	if buffer.IsIntialized() {
+		if buffer.closed() {
+			return ErrClosed
+		}
+		return nil
+	}
+
+	return buffer.initialize()
+}
+
 // Push appends an item to the end of the buffer.
 //
+// It returns an ErrTimeout if if cannot be performed in a timely fashion, an
+// ErrZeroValue if zero values are rejected, an ErrInvalidItem joined with the
+// validation error if the item validator rejects the item, an ErrNotStarted if
+// the buffer requires an explicit start and has not been started, and an
+// ErrClosed once Close has been called, even while the final flush is still
+// running, unless configured otherwise with WithClosedPushBehavior. An
+// ErrTimeout is joined with an ErrBufferFull or an ErrFlushBlocked that tells
+// why the push stalled.
+func (buffer *Buffer[T]) Push(item T) error {
+	return buffer.push(entry[T]{item: item}, false)
+}
+
+// PushWithAck appends an item to the end of the buffer like Push, and calls ack
+// once the batch containing the item has been flushed, with the error of that
+// flush. This allows acknowledging the item upstream only once it has been
+// written, for at-least-once delivery.
+//
+// Ack is called exactly once, on one of the buffer's goroutines, and only once
+// the item's fate is final: an item requeued by WithRequeueOnError is
+// acknowledged after its last attempt. An item that is dropped by eviction is acknowledged
+// with an ErrEvicted, an item that is dropped by sampling or deduplication is
+// acknowledged without an error, and an item that is discarded on close or
+// dropped because the buffer is closed is acknowledged with an ErrClosed. Ack is
+// not called when PushWithAck returns an error.
+func (buffer *Buffer[T]) PushWithAck(item T, ack func(err error)) error {
+	return buffer.push(entry[T]{item: item, ack: ack}, false)
+}
+
+// PushWithAckContext behaves like PushWithAck, and remembers the context the
+// item was pushed with to hand it to ack along with the error, for instance to
+// end a trace span. With WithSkipCancelled, the item is not written when its
+// context is done by the time it is flushed, and ack is called with the
+// context's error instead. A context-aware flusher finds the contexts of the
+// items it writes with ItemContextsFromContext. Ack may be nil.
+func (buffer *Buffer[T]) PushWithAckContext(ctx context.Context, item T, ack func(ctx context.Context, err error)) error {
+	e := entry[T]{item: item, ctx: ctx}
+	if ack != nil {
+		e.ack = func(err error) { ack(ctx, err) }
+	}
+
+	return buffer.push(e, false)
+}
+
+// PushX appends an item to the end of the buffer like Push, and reports whether
+// accepting it triggered a flush, typically because it filled the buffer.
+//
+// It is subject to the push timeout like Push. Once the item is accepted, it
+// does not wait for the triggered flush to be written, but it does wait for a
+// flush that makes room for the item under the memory limit. It reports false
+// when the item is silently dropped because the buffer is closed.
+func (buffer *Buffer[T]) PushX(item T) (flushed bool, err error) {
+	e := entry[T]{item: item, flushed: make(chan bool, 1)}
+	if err := buffer.push(e, false); err != nil {
+		return false, err
+	}
+
+	return <-e.flushed, nil
+}
+
+// PushPriority appends an item to the end of the buffer and flushes the buffer
+// right after, guaranteeing the item is part of the flushed batch.
+//
 // It returns an ErrTimeout if if cannot be performed in a timely fashion, and
 // an ErrClosed if the buffer has been closed.
-func (buffer *Buffer[T]) Push(item T) error {
+func (buffer *Buffer[T]) PushPriority(item T) error {
+	return buffer.push(entry[T]{item: item}, true)
+}
+
+func (buffer *Buffer[T]) push(e entry[T], priority bool) error {
+	if buffer.PushHook == nil {
+		_, err := buffer.enqueue(e, priority)
+		return err
+	}
+
+	accepted, err := buffer.enqueue(e, priority)
+	buffer.PushHook(e.item, accepted)
+	return err
+}
+
+// enqueue hands an item to the consume goroutine, reporting whether it was
+// accepted.
+func (buffer *Buffer[T]) enqueue(e entry[T], priority bool) (bool, error) {
 	if !buffer.IsIntialized() {
+		if buffer.RequireStart {
+			return false, ErrNotStarted
+		}
+
 		// validate the options
 		err := buffer.Validate()
 		if err != nil {
-			return err
+			return false, err
 		}
 
 		// initialize the buffer
 		err = buffer.initialize()
 		if err != nil {
-			return err
+			return false, err
+		}
+	}
+
+	if buffer.closing() {
+		return buffer.refuse(e)
+	}
+	if buffer.IsZero != nil && buffer.IsZero(e.item) {
+		return false, ErrZeroValue
+	}
+	if buffer.ItemValidator != nil {
+		if err := buffer.ItemValidator(e.item); err != nil {
+			return false, errors.Join(ErrInvalidItem, err)
+		}
+	}
+
+	if buffer.Synchronous {
+		accepted := false
+		buffer.inline(func(c *consumer[T]) {
+			if c.closing {
+				return
+			}
+			accepted = true
+			if !priority {
+				c.push(e)
+				return
+			}
+			c.add(e)
+			c.flush(c.count, flushRequest[T]{reason: FlushReasonPriority})
+		})
+		if !accepted {
+			return buffer.refuse(e)
+		}
+		return true, nil
+	}
+
+	buffer.sendMu.RLock()
+	defer buffer.sendMu.RUnlock()
+	if buffer.closing() {
+		return buffer.refuse(e)
+	}
+
+	ch := buffer.dataCh
+	if priority {
+		ch = buffer.priorityCh
+	}
+
+	// fast path: hand the item off right away when the consume goroutine is
+	// ready for it, without setting up a timer
+	select {
+	case ch <- e:
+		return true, nil
+	default:
+	}
+
+	if buffer.PushRetryAttempts > 0 {
+		return buffer.retrySend(ch, e)
+	}
+
+	select {
+	case ch <- e:
+		return true, nil
+	case <-buffer.closeCh:
+		return buffer.refuse(e)
+	case <-time.After(buffer.pushTimeout()):
+		buffer.Metrics.IncDropped(1)
+		return false, buffer.timeoutError("push", buffer.stalled())
+	}
+}
+
+// refuse turns away an item pushed once Close has been called, according to
+// the closed push behavior.
+func (buffer *Buffer[T]) refuse(e entry[T]) (bool, error) {
+	if buffer.ClosedPushBehavior == SilentlyDrop {
+		e.acknowledge(ErrClosed)
+		e.report(false)
+		return false, nil
+	}
+
+	return false, ErrClosed
+}
+
+// retrySend hands an item off to the consume goroutine within the configured
+// number of attempts, doubling the wait after every attempt, and gives up as
+// soon as the buffer starts closing, see WithPushRetry.
+func (buffer *Buffer[T]) retrySend(ch chan<- entry[T], e entry[T]) (bool, error) {
+	wait := buffer.PushRetryBackoff
+	for attempt := 0; attempt < buffer.PushRetryAttempts; attempt++ {
+		timer := time.NewTimer(wait)
+		select {
+		case ch <- e:
+			timer.Stop()
+			return true, nil
+		case <-buffer.closeCh:
+			timer.Stop()
+			return buffer.refuse(e)
+		case <-timer.C:
 		}
+		wait *= 2
 	}
 
+	buffer.Metrics.IncDropped(1)
+	return false, buffer.timeoutError("push", buffer.stalled())
+}
+
+func (e entry[T]) acknowledge(err error) {
+	if e.ack != nil {
+		e.ack(err)
+	}
+}
+
+func (e entry[T]) report(flushed bool) {
+	if e.flushed != nil {
+		e.flushed <- flushed
+	}
+}
+
+// acknowledge calls every non-nil ack with err.
+func acknowledge(acks []func(err error), err error) {
+	for _, ack := range acks {
+		if ack != nil {
+			ack(err)
+		}
+	}
+}
+
+// timeoutError builds the error returned when op timed out, joining the custom
+// timeout error if there is one with cause and ErrTimeout.
+func (buffer *Buffer[T]) timeoutError(op string, cause error) error {
+	var custom error
+	if buffer.TimeoutError != nil {
+		custom = buffer.TimeoutError(op)
+	}
+
+	return errors.Join(custom, cause, ErrTimeout)
+}
+
+// stalled diagnoses why the consume goroutine did not accept a push in time.
+func (buffer *Buffer[T]) stalled() error {
+	if buffer.flushing.Load() {
+		return ErrFlushBlocked
+	}
+
+	return ErrBufferFull
+}
+
+// Flush outputs the buffer to a permanent destination.
+//
+// Calls made while a previous Flush is still waiting for the buffer coalesce
+// into it, as the pending flush covers every item pushed so far, and return its
+// outcome once it has been delivered. A flush of an empty buffer never reaches
+// the flusher.
+//
+// It returns an ErrTimeout if if cannot be performed in a timely fashion, an
+// ErrNotInitialized if nothing has been pushed yet, and an ErrClosed if the
+// buffer has been closed.
+func (buffer *Buffer[T]) Flush() error {
+	buffer.flushMu.Lock()
+	if pending := buffer.flushPending; pending != nil && buffer.IsIntialized() && !buffer.closed() {
+		buffer.flushMu.Unlock()
+
+		// the pending flush covers this one
+		<-pending.done
+		return pending.err
+	}
+	pending := &pendingFlush{done: make(chan struct{})}
+	buffer.flushPending = pending
+	buffer.flushMu.Unlock()
+
+	pending.err = buffer.requestFlush(flushRequest[T]{pending: pending})
+	buffer.taken(pending)
+	close(pending.done)
+
+	return pending.err
+}
+
+// taken stops a pending Flush from taking in other calls, as the items pushed
+// from now on are not covered by its request.
+func (buffer *Buffer[T]) taken(pending *pendingFlush) {
+	if pending == nil {
+		return
+	}
+
+	buffer.flushMu.Lock()
+	if buffer.flushPending == pending {
+		buffer.flushPending = nil
+	}
+	buffer.flushMu.Unlock()
+}
+
+// FlushContext outputs the buffer to a permanent destination like Flush, and
+// hands ctx to the flusher when it is context-aware, see WithContextFlusher.
+// Flushes triggered by size or interval use the background context instead.
+//
+// It returns an ErrTimeout if if cannot be performed in a timely fashion, the
+// context's error if the context is done first, an ErrNotInitialized if nothing
+// has been pushed yet, and an ErrClosed if the buffer has been closed.
+func (buffer *Buffer[T]) FlushContext(ctx context.Context) error {
+	if !buffer.IsIntialized() {
+		return ErrNotInitialized
+	}
 	if buffer.closed() {
 		return ErrClosed
 	}
 
+	request := flushRequest[T]{ctx: ctx}
+	if buffer.Synchronous {
+		buffer.inline(func(c *consumer[T]) { c.handle(request) })
+		return nil
+	}
+
 	select {
-	case buffer.dataCh <- item:
+	case buffer.flushCh <- request:
 		return nil
-	case <-time.After(buffer.PushTimeout):
-		return errors.Join(errors.New("buffer is full"), ErrTimeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(buffer.flushTimeout()):
+		return buffer.timeoutError("flush", errors.New("failed to flush buffer within flush timeout"))
 	}
 }
 
-// Flush outputs the buffer to a permanent destination.
+// FlushOlderThan outputs only the buffered items that were pushed more than age
+// ago, leaving newer items in the buffer.
 //
-// It returns an ErrTimeout if if cannot be performed in a timely fashion, and
-// an ErrClosed if the buffer has been closed.
-func (buffer *Buffer[T]) Flush() error {
+// It returns an ErrTimeout if if cannot be performed in a timely fashion, an
+// ErrNotInitialized if nothing has been pushed yet, and an ErrClosed if the
+// buffer has been closed.
+func (buffer *Buffer[T]) FlushOlderThan(age time.Duration) error {
+	return buffer.requestFlush(flushRequest[T]{olderThan: age, partial: true})
+}
+
+// FlushAndWait outputs the buffer to a permanent destination and waits for the
+// flush to complete.
+//
+// It returns the flush error if the batch could not be written, the context's
+// error if the context is done first, an ErrNotInitialized if nothing has been
+// pushed yet, and an ErrClosed if the buffer has been closed.
+func (buffer *Buffer[T]) FlushAndWait(ctx context.Context) error {
+	_, err := buffer.awaitFlush(ctx, flushRequest[T]{})
+	return err
+}
+
+// FlushAsync behaves like FlushAndWait without blocking: it returns a channel
+// of its own that receives the outcome of the flush once it has completed, and
+// is closed right after. Ignoring the channel is fine.
+func (buffer *Buffer[T]) FlushAsync() <-chan error {
+	result := make(chan error, 1)
+	go func() {
+		defer close(result)
+
+		_, err := buffer.awaitFlush(context.Background(), flushRequest[T]{})
+		result <- err
+	}()
+
+	return result
+}
+
+// Barrier flushes the buffer, waits for the flush and every other flush in
+// flight to complete, and then runs fn, all while the consume goroutine takes
+// in no pushes, so that nothing is buffered or being written while fn runs. fn
+// is not run if the flush fails. It runs on the consume goroutine, which
+// resumes serving pushes once it returns. With a channel buffer, pushes are
+// still accepted into the queue while fn runs, see WithChannelBuffer, and their
+// items are only buffered and flushed once it returns.
+//
+// It returns the flush error or the error of fn, the context's error if the
+// context is done first, in which case fn may still run, an ErrNotInitialized
+// if nothing has been pushed yet, and an ErrClosed if the buffer has been
+// closed.
+func (buffer *Buffer[T]) Barrier(ctx context.Context, fn func() error) error {
+	_, err := buffer.awaitFlush(ctx, flushRequest[T]{barrier: fn})
+	return err
+}
+
+// FlushWithin behaves like FlushAndWait, with a duration rather than a context.
+//
+// It returns an ErrTimeout if the flush does not complete within d, in which
+// case the flush carries on and the buffer remains usable.
+func (buffer *Buffer[T]) FlushWithin(d time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	_, err := buffer.awaitFlush(ctx, flushRequest[T]{})
+	if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return buffer.timeoutError("flush", fmt.Errorf("failed to flush buffer within %v", d))
+	}
+
+	return err
+}
+
+// FlushReturn behaves like FlushAndWait, and also returns a copy of exactly the
+// items that were flushed. It returns an empty slice when nothing was buffered.
+func (buffer *Buffer[T]) FlushReturn(ctx context.Context) ([]T, error) {
+	return buffer.awaitFlush(ctx, flushRequest[T]{collect: true})
+}
+
+func (buffer *Buffer[T]) awaitFlush(ctx context.Context, request flushRequest[T]) ([]T, error) {
+	if !buffer.IsIntialized() {
+		return nil, ErrNotInitialized
+	}
+	if buffer.closed() {
+		return nil, ErrClosed
+	}
+
+	request.reply = make(chan flushReply[T], 1)
+	if buffer.Synchronous {
+		buffer.inline(func(c *consumer[T]) { c.handle(request) })
+		reply := <-request.reply
+		return reply.items, reply.err
+	}
+
+	select {
+	case buffer.flushCh <- request:
+	case <-buffer.doneCh:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case reply := <-request.reply:
+		return reply.items, reply.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (buffer *Buffer[T]) requestFlush(request flushRequest[T]) error {
+	if !buffer.IsIntialized() {
+		return ErrNotInitialized
+	}
 	if buffer.closed() {
 		return ErrClosed
 	}
 
+	if buffer.Synchronous {
+		buffer.inline(func(c *consumer[T]) { c.handle(request) })
+		return nil
+	}
+
 	select {
-	case buffer.flushCh <- struct{}{}:
+	case buffer.flushCh <- request:
 		return nil
-	case <-time.After(buffer.FlushTimeout):
-		return errors.Join(errors.New("failed to flush buffer within flush timeout"), ErrTimeout)
+	case <-time.After(buffer.flushTimeout()):
+		return buffer.timeoutError("flush", errors.New("failed to flush buffer within flush timeout"))
 	}
 }
 
 // Close flushes the buffer and prevents it from being further used.
 //
-// It returns an ErrTimeout if if cannot be performed in a timely fashion, and
-// an ErrClosed if the buffer has already been closed.
+// It returns an ErrTimeout if if cannot be performed in a timely fashion, an
+// ErrNotInitialized if nothing has been pushed yet, and an ErrClosed if the
+// buffer has already been closed.
 //
-// An ErrTimeout can either mean that a flush could not be triggered, or it can
-// mean that a flush was triggered but it has not finished yet. In any case it is
-// safe to call Close again.
+// Close signals the consume goroutine right away, even while it is busy writing
+// a batch, so the close timeout is spent waiting for the final flush only. An
+// ErrTimeout means that the final flush has not finished yet, in which case it
+// is safe to call Close again. It is joined with an ErrDataLoss when items were
+// still unwritten, see Lost.
 func (buffer *Buffer[T]) Close() error {
-	if buffer.closed() {
+	if !buffer.IsIntialized() {
+		return ErrNotInitialized
+	}
+	if buffer.released.Load() {
 		return ErrClosed
 	}
 
+	buffer.closeOnce.Do(func() {
+		close(buffer.closeCh)
+		if buffer.Synchronous {
+			buffer.inline(func(c *consumer[T]) {
+				c.shutdown()
+				c.close()
+			})
+		}
+	})
+
 	select {
-	case buffer.closeCh <- struct{}{}:
-		// noop
-	case <-time.After(buffer.CloseTimeout):
-		return errors.Join(errors.New("failed to close buffer within close timeout"), ErrTimeout)
+	case <-buffer.doneCh:
+		if !buffer.released.CompareAndSwap(false, true) {
+			return ErrClosed
+		}
+		// the channels are left open: a push or flush request racing with
+		// Close may still be selecting on them, and gives up on doneCh
+		buffer.lost.Store(0)
+		return buffer.closeErr
+	case <-time.After(buffer.closeTimeout()):
+		err := buffer.timeoutError("close", errors.New("failed to close buffer within close timeout"))
+		lost := buffer.Len()
+		buffer.lost.Store(int64(lost))
+		if lost > 0 {
+			err = errors.Join(err, fmt.Errorf("%w: %d items", ErrDataLoss, lost))
+		}
+		return err
+	}
+}
+
+// Lost returns the number of items that were still unwritten when Close last
+// timed out, which are lost if Close is not called again. Items being written by
+// overlapping flushes are not included. It returns zero once Close succeeds.
+func (buffer *Buffer[T]) Lost() int {
+	return int(buffer.lost.Load())
+}
+
+// DrainToSlice closes the buffer and returns the items that were buffered,
+// bypassing the flusher, for instance when the sink is the caller itself. Items
+// pushed concurrently with DrainToSlice may still be flushed by the close, and
+// items being written by overlapping flushes are not returned. To flush the
+// items and inspect them as well, call FlushReturn and then Close.
+//
+// It returns an ErrTimeout if the items cannot be collected within the close
+// timeout, an ErrNotInitialized if nothing has been pushed yet, an ErrClosed if
+// the buffer has been closed, and any error of Close otherwise.
+func (buffer *Buffer[T]) DrainToSlice() ([]T, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), buffer.closeTimeout())
+	defer cancel()
+
+	items, err := buffer.awaitFlush(ctx, flushRequest[T]{drain: true})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, buffer.timeoutError("close", errors.New("failed to drain buffer within close timeout"))
+		}
+		return nil, err
+	}
+
+	return items, buffer.Close()
+}
+
+// Wait blocks until the buffer has been fully closed, meaning the final flush
+// has completed and the consume goroutine has exited.
+//
+// It returns immediately if the buffer was never initialized, and returns the
+// context's error if the context is done before the buffer is closed.
+func (buffer *Buffer[T]) Wait(ctx context.Context) error {
+	if !buffer.IsIntialized() {
+		return nil
 	}
 
 	select {
 	case <-buffer.doneCh:
-		close(buffer.dataCh)
-		close(buffer.flushCh)
-		close(buffer.closeCh)
 		return nil
-	case <-time.After(buffer.CloseTimeout):
-		return errors.Join(errors.New("failed to close buffer within close timeout"), ErrTimeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WaitEmpty blocks until the buffer holds no items, which typically happens
+// right after a flush, without closing it. With overlapping flushes the last
+// batches may still be in flight when it returns.
+//
+// It returns immediately if the buffer was never initialized, an ErrClosed if
+// the buffer is closed while items are still buffered, and the context's error
+// if the context is done before the buffer is empty.
+func (buffer *Buffer[T]) WaitEmpty(ctx context.Context) error {
+	if !buffer.IsIntialized() {
+		return nil
+	}
+
+	emptied := buffer.emptied.wait()
+	if buffer.Len() == 0 {
+		return nil
 	}
+
+	select {
+	case <-emptied:
+		return nil
+	case <-buffer.doneCh:
+		if buffer.Len() == 0 {
+			return nil
+		}
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Discarded returns the items that were still buffered when the buffer was
+// closed without a final flush, see WithFlushOnClose. It returns nil until the
+// buffer is fully closed.
+func (buffer *Buffer[T]) Discarded() []T {
+	if !buffer.IsIntialized() || !buffer.closed() {
+		return nil
+	}
+
+	return buffer.discarded
 }
 
-func (buffer Buffer[T]) closed() bool {
+func (buffer *Buffer[T]) closed() bool {
 	select {
 	case <-buffer.doneCh:
 		return true
@@ -120,41 +886,176 @@ func (buffer Buffer[T]) closed() bool {
 	}
 }
 
-func (buffer *Buffer[T]) consume() {
-	count := 0
-	items := make([]T, buffer.Size)
-	mustFlush := false
-	ticker, stopTicker := newTicker(buffer.FlushInterval)
+// closing reports whether Close has been called, from then on pushes are
+// refused even though the final flush may still be running.
+func (buffer *Buffer[T]) closing() bool {
+	select {
+	case <-buffer.closeCh:
+		return true
+	default:
+		return false
+	}
+}
 
-	isOpen := true
-	for isOpen {
-		select {
-		case item := <-buffer.dataCh:
-			items[count] = item
-			count++
-			mustFlush = count >= len(items)
-		case <-ticker:
-			mustFlush = count > 0
-		case <-buffer.flushCh:
-			mustFlush = count > 0
-		case <-buffer.closeCh:
-			isOpen = false
-			mustFlush = count > 0
+// flush writes a batch to the flusher, routing any error to the error handler.
+//
+// The stamps hold the push time of every item for a timestamped flusher, and are
+// nil otherwise. It returns the items that could not be written along with the
+// error.
+func (buffer *Buffer[T]) flush(ctx context.Context, items []T, stamps []time.Time, reason FlushReason) ([]T, error) {
+	if buffer.CopyOnFlush || buffer.FlushOrder == LIFO {
+		items = append([]T(nil), items...)
+	}
+	if buffer.FlushOrder == LIFO {
+		stamps = slices.Clone(stamps)
+		slices.Reverse(items)
+		slices.Reverse(stamps)
+	}
+
+	buffer.subscribers.emit(Event{Type: EventFlushStarted, Size: len(items), Reason: reason})
+	ctx = context.WithValue(ctx, flushReasonKey{}, reason)
+
+	var (
+		failed []T
+		err    error
+	)
+	if buffer.BatchValidator != nil {
+		err = buffer.BatchValidator(items)
+		if err != nil {
+			err = errors.Join(ErrInvalidBatch, err)
+			failed = items
+			buffer.Metrics.IncErrors(1)
+			buffer.fail(err, items)
+		}
+	}
+	if err == nil && buffer.BatchTransform != nil {
+		items = buffer.BatchTransform(items)
+		if len(items) != len(stamps) {
+			// the push times no longer match the items
+			stamps = nil
 		}
+	}
+	if err == nil && len(items) > 0 {
+		groups, groupStamps := buffer.group(items, stamps)
+		for i, group := range groups {
+			for offset := 0; offset < len(group); {
+				chunk := buffer.chunk(group[offset:])
+				var chunkStamps []time.Time
+				if groupStamps != nil {
+					chunkStamps = groupStamps[i][offset : offset+len(chunk)]
+				}
+				start := time.Now()
+				chunkFailed, chunkErr := buffer.write(ctx, chunk, chunkStamps)
+				buffer.Metrics.ObserveFlushDuration(time.Since(start))
+				buffer.Metrics.IncFlushed(1, len(chunk))
+				if chunkErr != nil {
+					// the rest of the group is failed along with this chunk
+					groupFailed := append(chunkFailed[:len(chunkFailed):len(chunkFailed)], group[offset+len(chunk):]...)
+					buffer.Metrics.IncErrors(1)
+					if !buffer.requeues(chunkErr) && !errors.Is(chunkErr, ErrFlusherPanicked) {
+						buffer.fail(chunkErr, groupFailed)
+					}
+					failed = append(failed, groupFailed...)
+					err = errors.Join(err, chunkErr)
+					break
+				}
+				offset += len(chunk)
+			}
+		}
+	}
 
-		if mustFlush {
-			stopTicker()
-			buffer.Flusher.Write(items[:count])
+	if err == nil && len(items) > 0 && buffer.AfterFlush != nil {
+		buffer.AfterFlush(len(items))
+	}
+	buffer.health.record(err)
+	buffer.watchdog.kick()
+	buffer.subscribers.emit(Event{Type: EventFlushCompleted, Size: len(items), Reason: reason, Err: err})
+	if err != nil && buffer.ErrChSize > 0 {
+		buffer.errCh.publish(buffer.ErrChSize, err)
+	}
+	if buffer.FlushDoneSignal {
+		buffer.flushDone.pulse()
+	}
 
-			count = 0
-			items = make([]T, buffer.Size)
-			mustFlush = false
-			ticker, stopTicker = newTicker(buffer.FlushInterval)
+	return failed, err
+}
+
+// closeFlushers closes every configured flusher that implements io.Closer, and
+// returns their errors joined together.
+func (buffer *Buffer[T]) closeFlushers() error {
+	var errs []error
+	for _, flusher := range []any{buffer.Flusher, buffer.ContextFlusher, buffer.TimestampedFlusher, buffer.PartialFlusher} {
+		if closer, ok := flusher.(io.Closer); ok {
+			errs = append(errs, closer.Close())
 		}
 	}
 
-	stopTicker()
-	close(buffer.doneCh)
+	return errors.Join(errs...)
+}
+
+// group partitions a batch by the flush group key, preserving the order of the
+// items within every group, and the order in which the groups first appear. The
+// stamps, if any, are partitioned along with the items.
+func (buffer *Buffer[T]) group(items []T, stamps []time.Time) ([][]T, [][]time.Time) {
+	if buffer.FlushGroupKey == nil {
+		if stamps == nil {
+			return [][]T{items}, nil
+		}
+		return [][]T{items}, [][]time.Time{stamps}
+	}
+
+	var (
+		groups      [][]T
+		groupStamps [][]time.Time
+	)
+	index := map[any]int{}
+	for j, item := range items {
+		key := buffer.FlushGroupKey(item)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+			if stamps != nil {
+				groupStamps = append(groupStamps, nil)
+			}
+		}
+		groups[i] = append(groups[i], item)
+		if stamps != nil {
+			groupStamps[i] = append(groupStamps[i], stamps[j])
+		}
+	}
+
+	return groups, groupStamps
+}
+
+// chunk returns the leading items that fit into a single call to the flusher.
+func (buffer *Buffer[T]) chunk(items []T) []T {
+	limit := buffer.MaxWriteBatch
+	if buffer.StrictSize && (limit == 0 || limit > int(buffer.Size)) {
+		limit = int(buffer.Size)
+	}
+	if limit > 0 && len(items) > limit {
+		return items[:limit]
+	}
+
+	return items
+}
+
+// waterMarks returns the configured pressure water marks, defaulting to a full
+// buffer for the high-water mark and half of it for the low-water mark.
+func (buffer *Buffer[T]) waterMarks() (uint, uint) {
+	high, low := buffer.HighWaterMark, buffer.LowWaterMark
+	if high == 0 {
+		// below Size, which triggers a flush that releases the pressure
+		// before anyone can tell
+		high = max(buffer.Size*4/5, 1)
+	}
+	if low == 0 {
+		low = (high + 1) / 2
+	}
+
+	return high, low
 }
 
 func newTicker(interval time.Duration) (<-chan time.Time, func()) {
@@ -185,6 +1086,92 @@ func New[T any](opts ...Option[T]) *Buffer[T] {
 	return buffer
 }
 
+// Clone returns a new, uninitialized buffer with the same options as this one.
+// The clone shares none of the original's state: it gets its own channels and
+// consume goroutine once it is initialized. Options are copied shallowly, so
+// the clone uses the very same flusher, hooks and metrics, and timeouts changed
+// at runtime are carried over as the clone's configured timeouts. The sampling
+// source of randomness is not copied, as a rand.Source is not safe for
+// concurrent use: every clone that needs deterministic sampling or retry jitter
+// has to be given sources of its own with WithRandSource and
+// WithRetryRandSource.
+func (b *Buffer[T]) Clone() *Buffer[T] {
+	return &Buffer[T]{
+		Size:                b.Size,
+		Flusher:             b.Flusher,
+		ContextFlusher:      b.ContextFlusher,
+		FlusherSelector:     b.FlusherSelector,
+		FlushInterval:       b.FlushInterval,
+		PushTimeout:         b.pushTimeout(),
+		FlushTimeout:        b.timeouts.flush.get(b.FlushTimeout),
+		PerItemFlushTimeout: b.PerItemFlushTimeout,
+		CloseTimeout:        b.closeTimeout(),
+		CopyOnFlush:         b.CopyOnFlush,
+		HighWaterMark:       b.HighWaterMark,
+		LowWaterMark:        b.LowWaterMark,
+		BatchValidator:      b.BatchValidator,
+		ErrorHandler:        b.ErrorHandler,
+		Retries:             b.Retries,
+		RetryBackoff:        b.RetryBackoff,
+		DeadLetter:          b.DeadLetter,
+		OverlappingFlush:    b.OverlappingFlush,
+		OnClose:             b.OnClose,
+		Metrics:             b.Metrics,
+		IsZero:              b.IsZero,
+		FlushLaneKey:        b.FlushLaneKey,
+		DiscardOnClose:      b.DiscardOnClose,
+		FlushDoneSignal:     b.FlushDoneSignal,
+		MemoryLimit:         b.MemoryLimit,
+		SizeOf:              b.SizeOf,
+		OnEvict:             b.OnEvict,
+		MemoryPolicy:        b.MemoryPolicy,
+		InitialDelay:        b.InitialDelay,
+		RequeueAttempts:     b.RequeueAttempts,
+		RequireStart:        b.RequireStart,
+		SampleRate:          b.SampleRate,
+		HealthFailures:      b.HealthFailures,
+		HealthWindow:        b.HealthWindow,
+		FlushGroupKey:       b.FlushGroupKey,
+		BatchTransform:      b.BatchTransform,
+		ExclusiveFlush:      b.ExclusiveFlush,
+		DedupKey:            b.DedupKey,
+		DedupWindow:         b.DedupWindow,
+		ClosedPushBehavior:  b.ClosedPushBehavior,
+		StableInterval:      b.StableInterval,
+		TimeoutError:        b.TimeoutError,
+		MaxWriteBatch:       b.MaxWriteBatch,
+		MaxInFlightBatches:  b.MaxInFlightBatches,
+		PanicHandler:        b.PanicHandler,
+		TimestampedFlusher:  b.TimestampedFlusher,
+		FlushDebounce:       b.FlushDebounce,
+		PartialFlusher:      b.PartialFlusher,
+		NoFlushOnFull:       b.NoFlushOnFull,
+		FlushTrigger:        b.FlushTrigger,
+		FlushOrder:          b.FlushOrder,
+		CloseFlusher:        b.CloseFlusher,
+		Watchdog:            b.Watchdog,
+		OnStuck:             b.OnStuck,
+		LastValueKey:        b.LastValueKey,
+		PushHook:            b.PushHook,
+		RetryJitter:         b.RetryJitter,
+		RetryMaxElapsed:     b.RetryMaxElapsed,
+		ItemValidator:       b.ItemValidator,
+		ChannelBuffer:       b.ChannelBuffer,
+		StrictSize:          b.StrictSize,
+		ErrChSize:           b.ErrChSize,
+		Synchronous:         b.Synchronous,
+		OnEmptyFlush:        b.OnEmptyFlush,
+		SkipCancelled:       b.SkipCancelled,
+		SizeSchedule:        b.SizeSchedule,
+		Clock:               b.Clock,
+		AfterFlush:          b.AfterFlush,
+		PushRetryAttempts:   b.PushRetryAttempts,
+		PushRetryBackoff:    b.PushRetryBackoff,
+		DistinctKey:         b.DistinctKey,
+		DistinctKeys:        b.DistinctKeys,
+	}
+}
+
 func (b *Buffer[T]) Validate() error {
 	return validateBuffer(b)
 }
@@ -199,12 +1186,27 @@ func (b *Buffer[T]) initialize() error {
 		return err
 	}
 
-	b.dataCh = make(chan T)
-	b.flushCh = make(chan struct{})
+	if b.Metrics == nil {
+		b.Metrics = noopMetrics{}
+	}
+	if b.RetryRandSource != nil {
+		b.retryRand.rand = rand.New(b.RetryRandSource)
+	}
+
+	b.dataCh = make(chan entry[T], b.ChannelBuffer)
+	b.priorityCh = make(chan entry[T])
+	b.flushCh = make(chan flushRequest[T])
 	b.closeCh = make(chan struct{})
 	b.doneCh = make(chan struct{})
+	b.statsCh = make(chan statsRequest)
+	b.resultCh = make(chan flushResult[T])
 
-	go b.consume()
+	b.subscribers.emit(Event{Type: EventInitialized})
+	if b.Synchronous {
+		b.direct = b.newConsumer()
+	} else {
+		go b.consume()
+	}
 
 	return nil
 }
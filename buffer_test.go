@@ -1,7 +1,13 @@
 package buffer_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -69,7 +75,8 @@ var _ = Describe("Buffer", func() {
 
 				err := buf.Push(0)
 
-				Expect(err).To(MatchError(fmt.Errorf(buffer.ErrInvalidInterval, "FlushInterval")))
+				Expect(err).To(MatchError(buffer.ErrInvalidInterval))
+				Expect(err).To(MatchError(ContainSubstring("FlushInterval")))
 			})
 
 			It("panics when provided an invalid push timeout", func() {
@@ -80,7 +87,8 @@ var _ = Describe("Buffer", func() {
 
 				err := buf.Push(0)
 
-				Expect(err).To(MatchError(fmt.Errorf(buffer.ErrInvalidTimeout, "PushTimeout")))
+				Expect(err).To(MatchError(buffer.ErrInvalidTimeout))
+				Expect(err).To(MatchError(ContainSubstring("PushTimeout")))
 			})
 
 			It("panics when provided an invalid flush timeout", func() {
@@ -91,7 +99,20 @@ var _ = Describe("Buffer", func() {
 
 				err := buf.Push(0)
 
-				Expect(err).To(MatchError(fmt.Errorf(buffer.ErrInvalidTimeout, "FlushTimeout")))
+				Expect(err).To(MatchError(buffer.ErrInvalidTimeout))
+				Expect(err).To(MatchError(ContainSubstring("FlushTimeout")))
+			})
+
+			It("panics when provided an invalid per-item flush timeout", func() {
+				buf := buffer.New[any]().
+					WithSize(1).
+					WithFlusher(flusher).
+					WithPerItemFlushTimeout(-1)
+
+				err := buf.Push(0)
+
+				Expect(err).To(MatchError(buffer.ErrInvalidTimeout))
+				Expect(err).To(MatchError(ContainSubstring("PerItemFlushTimeout")))
 			})
 
 			It("panics when provided an invalid close timeout", func() {
@@ -102,11 +123,296 @@ var _ = Describe("Buffer", func() {
 
 				err := buf.Push(0)
 
-				Expect(err).To(MatchError(fmt.Errorf(buffer.ErrInvalidTimeout, "CloseTimeout")))
+				Expect(err).To(MatchError(buffer.ErrInvalidTimeout))
+				Expect(err).To(MatchError(ContainSubstring("CloseTimeout")))
+			})
+
+			It("panics when provided an invalid sample rate", func() {
+				buf := buffer.New[any]().
+					WithSize(1).
+					WithFlusher(flusher).
+					WithSampleRate(1.5)
+
+				err := buf.Push(0)
+
+				Expect(err).To(MatchError(buffer.ErrInvalidSampleRate))
+			})
+
+			It("panics when provided negative requeue attempts", func() {
+				buf := buffer.New[any]().
+					WithSize(1).
+					WithFlusher(flusher).
+					WithRequeueOnError(-1)
+
+				err := buf.Push(0)
+
+				Expect(err).To(MatchError(buffer.ErrInvalidRequeueAttempts))
+			})
+
+			It("panics when provided a negative max write batch", func() {
+				buf := buffer.New[any]().
+					WithSize(1).
+					WithFlusher(flusher).
+					WithMaxWriteBatch(-1)
+
+				err := buf.Push(0)
+
+				Expect(err).To(MatchError(buffer.ErrInvalidMaxWriteBatch))
+			})
+
+			It("panics when provided negative max in-flight batches", func() {
+				buf := buffer.New[any]().
+					WithSize(1).
+					WithFlusher(flusher).
+					WithMaxInFlightBatches(-1)
+
+				err := buf.Push(0)
+
+				Expect(err).To(MatchError(buffer.ErrInvalidMaxInFlightBatches))
+			})
+
+			It("panics when disabling flush on full without a flush interval", func() {
+				buf := buffer.New[any]().
+					WithSize(1).
+					WithFlusher(flusher).
+					WithFlushOnFull(false)
+
+				err := buf.Push(0)
+
+				Expect(err).To(MatchError(buffer.ErrInvalidFlushOnFull))
+			})
+
+			It("panics when provided an invalid retry jitter", func() {
+				buf := buffer.New[any]().
+					WithSize(1).
+					WithFlusher(flusher).
+					WithRetryJitter(1.5)
+
+				err := buf.Push(0)
+
+				Expect(err).To(MatchError(buffer.ErrInvalidRetryJitter))
+			})
+
+			It("panics when provided a channel buffer smaller than the size", func() {
+				buf := buffer.New[any]().
+					WithSize(4).
+					WithFlusher(flusher).
+					WithChannelBuffer(2)
+
+				err := buf.Push(0)
+
+				Expect(err).To(MatchError(buffer.ErrInvalidChannelBuffer))
+			})
+
+			It("panics when provided a negative channel buffer", func() {
+				buf := buffer.New[any]().
+					WithSize(1).
+					WithFlusher(flusher).
+					WithChannelBuffer(-1)
+
+				err := buf.Push(0)
+
+				Expect(err).To(MatchError(buffer.ErrInvalidChannelBuffer))
+			})
+
+			It("panics when provided a negative distinct key trigger", func() {
+				buf := buffer.New[int](buffer.WithDistinctKeyTrigger(func(item int) int { return item }, -1)).
+					WithSize(1).
+					WithFlusher(buffer.FlusherFunc[int](func([]int) error { return nil }))
+
+				err := buf.Push(0)
+
+				Expect(err).To(MatchError(buffer.ErrInvalidDistinctKeys))
+			})
+
+			It("panics when provided push retries without a backoff", func() {
+				buf := buffer.New[any]().
+					WithSize(1).
+					WithFlusher(flusher).
+					WithPushRetry(3, 0)
+
+				err := buf.Push(0)
+
+				Expect(err).To(MatchError(buffer.ErrInvalidPushRetry))
+			})
+
+			It("panics when provided a negative error channel size", func() {
+				buf := buffer.New[any]().
+					WithSize(1).
+					WithFlusher(flusher).
+					WithErrCh(-1)
+
+				err := buf.Push(0)
+
+				Expect(err).To(MatchError(buffer.ErrInvalidErrCh))
+			})
+
+			It("panics when provided a watchdog without a stuck function", func() {
+				buf := buffer.New[any]().
+					WithSize(1).
+					WithFlusher(flusher).
+					WithWatchdog(time.Second, nil)
+
+				err := buf.Push(0)
+
+				Expect(err).To(MatchError(buffer.ErrInvalidWatchdog))
+			})
+
+			It("panics when provided a memory limit without a size function", func() {
+				buf := buffer.New[any]().
+					WithSize(1).
+					WithFlusher(flusher).
+					WithMemoryLimit(10, nil, nil)
+
+				err := buf.Push(0)
+
+				Expect(err).To(MatchError(buffer.ErrInvalidMemoryLimit))
+			})
+
+			It("panics when provided invalid water marks", func() {
+				buf := buffer.New[any]().
+					WithSize(2).
+					WithFlusher(flusher).
+					WithWaterMarks(1, 2)
+
+				err := buf.Push(0)
+
+				Expect(err).To(MatchError(buffer.ErrInvalidMarks))
 			})
 		})
 	})
 
+	Context("Cloning", func() {
+		It("copies the options", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(flusher).
+				WithFlushInterval(time.Minute).
+				WithRetries(2, time.Millisecond)
+			_ = sut.SetPushTimeout(5 * time.Second)
+
+			// act
+			clone := sut.Clone()
+
+			// assert
+			Expect(clone.Size).To(BeIdenticalTo(uint(3)))
+			Expect(clone.Flusher).To(BeIdenticalTo(flusher))
+			Expect(clone.FlushInterval).To(Equal(time.Minute))
+			Expect(clone.Retries).To(BeIdenticalTo(uint(2)))
+			Expect(clone.RetryBackoff).To(Equal(time.Millisecond))
+			Expect(clone.PushTimeout).To(Equal(5 * time.Second))
+			Expect(clone.IsIntialized()).To(BeFalse())
+		})
+
+		It("does not share the sources of randomness", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(flusher).
+				WithSampleRate(0.5).
+				WithRandSource(rand.NewSource(1)).
+				WithRetryJitter(0.5).
+				WithRetryRandSource(rand.NewSource(1))
+
+			// act
+			clone := sut.Clone()
+
+			// assert
+			Expect(clone.SampleRate).To(Equal(0.5))
+			Expect(clone.RandSource).To(BeNil())
+			Expect(clone.RetryJitter).To(Equal(0.5))
+			Expect(clone.RetryRandSource).To(BeNil())
+		})
+
+		It("does not share state with the original", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+			clone := sut.Clone()
+
+			// act
+			err1 := clone.Push(2)
+			err2 := sut.Close()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(err2).To(Succeed())
+			Expect(clone.Len()).To(Equal(1))
+			Expect(flusher.Done).To(Receive())
+			Expect(clone.Close()).To(Succeed())
+		})
+	})
+
+	Context("Starting", func() {
+		It("initializes the buffer up front", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher)
+
+			// act
+			err := sut.Start()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(sut.IsIntialized()).To(BeTrue())
+			Expect(sut.Flush()).To(Succeed())
+			Expect(sut.Close()).To(Succeed())
+		})
+
+		It("fails eagerly when provided invalid options", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithFlusher(flusher)
+
+			// act
+			err := sut.Start()
+
+			// assert
+			Expect(err).To(MatchError(buffer.ErrInvalidSize))
+		})
+
+		It("rejects pushes before Start when an explicit start is required", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher).
+				WithExplicitStart()
+
+			// act
+			err := sut.Push(1)
+			err1 := sut.Start()
+			err2 := sut.Push(1)
+
+			// assert
+			Expect(err).To(MatchError(buffer.ErrNotStarted))
+			Expect(err1).To(Succeed())
+			Expect(err2).To(Succeed())
+			Expect(sut.Close()).To(Succeed())
+		})
+
+		It("fails when the buffer is closed", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher)
+
+			_ = sut.Start()
+			_ = sut.Close()
+
+			// act
+			err := sut.Start()
+
+			// assert
+			Expect(err).To(MatchError(buffer.ErrClosed))
+		})
+	})
+
 	Context("Pushing", func() {
 		It("pushes items into the buffer when Push is called", func() {
 			// arrange
@@ -125,162 +431,2627 @@ var _ = Describe("Buffer", func() {
 			Expect(err3).To(Succeed())
 		})
 
-		It("fails when Push cannot execute in a timely fashion", func() {
+		It("reports whether a push triggered a flush when PushX is called", func() {
+			// arrange
+			flusher.Func = func() { time.Sleep(200 * time.Millisecond) }
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher)
+
+			// act
+			flushed1, err1 := sut.PushX(1)
+			start := time.Now()
+			flushed2, err2 := sut.PushX(2)
+
+			// assert
+			Expect(err1).To(Succeed())
+			Expect(err2).To(Succeed())
+			Expect(flushed1).To(BeFalse())
+			Expect(flushed2).To(BeTrue())
+			Expect(time.Since(start)).To(BeNumerically("<", 100*time.Millisecond))
+			Eventually(flusher.Done).Should(Receive())
+		})
+
+		It("reports no flush when PushX drops the item of a closed buffer", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher).
+				WithClosedPushBehavior(buffer.SilentlyDrop)
+
+			_ = sut.Push(1)
+			_ = sut.Close()
+
+			// act
+			flushed, err := sut.PushX(2)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(flushed).To(BeFalse())
+		})
+
+		It("flushes right after a priority item is pushed", func(done Done) {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(5).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+
+			// act
+			err1 := sut.PushPriority(2)
+
+			// assert
+			result := <-flusher.Done
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(result.Items).To(Equal([]any{1, 2}))
+			close(done)
+		})
+
+		It("rejects zero values when configured to", func() {
+			// arrange
+			sut := buffer.New[*int]().
+				WithSize(2).
+				WithFlusher(NewMockFlusher[*int]()).
+				WithRejectZeroValue(nil)
+
+			// act
+			err1 := sut.Push(new(int))
+			err2 := sut.Push(nil)
+
+			// assert
+			Expect(err1).To(Succeed())
+			Expect(err2).To(MatchError(buffer.ErrZeroValue))
+		})
+
+		It("rejects items matching the provided zero value check", func() {
+			// arrange
+			sut := buffer.New[string]().
+				WithSize(2).
+				WithFlusher(NewMockFlusher[string]()).
+				WithRejectZeroValue(func(item string) bool { return item == "-" })
+
+			// act
+			err1 := sut.Push("")
+			err2 := sut.Push("-")
+
+			// assert
+			Expect(err1).To(Succeed())
+			Expect(err2).To(MatchError(buffer.ErrZeroValue))
+		})
+
+		It("queues up pushes in the channel buffer while flushing", func() {
+			// arrange
+			release := make(chan struct{})
+			batches := NewMockFlusher[int]()
+			batches.Done = make(chan *WriteCall[int], 3)
+			batches.Func = func() { <-release }
+			sut := buffer.New[int]().
+				WithSize(1).
+				WithFlusher(batches).
+				WithPushTimeout(50 * time.Millisecond).
+				WithChannelBuffer(2)
+
+			// act
+			err1 := sut.Push(1)
+			err2 := sut.Push(2)
+			err3 := sut.Push(3)
+			err4 := sut.Push(4)
+			close(release)
+			err5 := sut.Close()
+
+			// assert
+			Expect(err1).To(Succeed())
+			Expect(err2).To(Succeed())
+			Expect(err3).To(Succeed())
+			Expect(err4).To(MatchError(buffer.ErrTimeout))
+			Expect(err5).To(Succeed())
+			var items []int
+			for call := range batches.Done {
+				items = append(items, call.Items...)
+				if len(items) == 3 {
+					break
+				}
+			}
+			Expect(items).To(Equal([]int{1, 2, 3}))
+		})
+
+		It("retries a push until the consumer catches up", func() {
+			// arrange
+			release := make(chan struct{})
+			batches := NewMockFlusher[int]()
+			batches.Done = make(chan *WriteCall[int], 2)
+			batches.Func = func() { <-release }
+			sut := buffer.New[int]().
+				WithSize(1).
+				WithFlusher(batches).
+				WithPushTimeout(time.Millisecond).
+				WithPushRetry(5, 20*time.Millisecond)
+
+			err := sut.Push(1)
+			time.AfterFunc(50*time.Millisecond, func() { close(release) })
+
+			// act
+			err1 := sut.Push(2)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			_ = sut.Close()
+		})
+
+		It("gives up on a push once every retry failed", func() {
+			// arrange
+			release := make(chan struct{})
+			batches := NewMockFlusher[int]()
+			batches.Func = func() { <-release }
+			sut := buffer.New[int]().
+				WithSize(1).
+				WithFlusher(batches).
+				WithPushTimeout(time.Minute).
+				WithPushRetry(2, 10*time.Millisecond)
+
+			err := sut.Push(1)
+
+			// act
+			start := time.Now()
+			err1 := sut.Push(2)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(MatchError(buffer.ErrTimeout))
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			close(release)
+			_ = sut.Close()
+		})
+
+		It("stops retrying a push as soon as the buffer closes", func() {
+			// arrange
+			started := make(chan struct{})
+			release := make(chan struct{})
+			batches := NewMockFlusher[int]()
+			batches.Func = func() {
+				close(started)
+				<-release
+			}
+			sut := buffer.New[int]().
+				WithSize(2).
+				WithFlusher(batches).
+				WithPushRetry(10, 100*time.Millisecond)
+
+			err := sut.Push(1)
+			go func() { _ = sut.Close() }()
+			<-started
+			time.AfterFunc(50*time.Millisecond, func() { close(release) })
+
+			// act
+			start := time.Now()
+			err1 := sut.Push(2)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(MatchError(buffer.ErrClosed))
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		})
+
+		It("rejects items that fail the item validator", func() {
+			// arrange
+			invalid := errors.New("negative")
+			batches := make(chan []int, 1)
+			sut := buffer.New[int]().
+				WithSize(2).
+				WithFlusher(buffer.NewChannelFlusher[int](batches, 0)).
+				WithItemValidator(func(item int) error {
+					if item < 0 {
+						return invalid
+					}
+					return nil
+				})
+
+			// act
+			err1 := sut.Push(1)
+			err2 := sut.Push(-1)
+			err3 := sut.Push(2)
+
+			// assert
+			Expect(err1).To(Succeed())
+			Expect(err2).To(MatchError(buffer.ErrInvalidItem))
+			Expect(err2).To(MatchError(invalid))
+			Expect(err3).To(Succeed())
+			Eventually(batches).Should(Receive(Equal([]int{1, 2})))
+			_ = sut.Close()
+		})
+
+		It("fails when Push cannot execute in a timely fashion", func() {
+			// arrange
+			flusher.Func = func() { select {} }
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher).
+				WithPushTimeout(time.Second)
+
+			// act
+			err1 := sut.Push(1)
+			err2 := sut.Push(2)
+			err3 := sut.Push(3)
+
+			// assert
+			Expect(err1).To(Succeed())
+			Expect(err2).To(Succeed())
+			Expect(err3).To(MatchError(buffer.ErrTimeout))
+			Expect(err3).To(MatchError(buffer.ErrFlushBlocked))
+		})
+
+		It("returns the custom timeout error when Push times out", func() {
+			// arrange
+			errUnavailable := errors.New("service unavailable")
+			var ops []string
+			flusher.Func = func() { select {} }
+			sut := buffer.New[any]().
+				WithSize(1).
+				WithFlusher(flusher).
+				WithPushTimeout(50 * time.Millisecond).
+				WithTimeoutError(func(op string) error {
+					ops = append(ops, op)
+					return errUnavailable
+				})
+
+			// act
+			err1 := sut.Push(1)
+			err2 := sut.Push(2)
+
+			// assert
+			Expect(err1).To(Succeed())
+			Expect(err2).To(MatchError(errUnavailable))
+			Expect(err2).To(MatchError(buffer.ErrTimeout))
+			Expect(err2).To(MatchError(buffer.ErrFlushBlocked))
+			Expect(ops).To(Equal([]string{"push"}))
+		})
+
+		It("tells that a push timed out waiting for a batch in flight", func() {
+			// arrange
+			release := make(chan struct{})
+			flusher.Func = func() { <-release }
+			sut := buffer.New[any]().
+				WithSize(1).
+				WithFlusher(flusher).
+				WithOverlappingFlush().
+				WithMaxInFlightBatches(1).
+				WithPushTimeout(50 * time.Millisecond)
+
+			// act
+			err1 := sut.Push(1)
+			err2 := sut.Push(2)
+			err3 := sut.Push(3)
+
+			// assert
+			Expect(err1).To(Succeed())
+			Expect(err2).To(Succeed())
+			Expect(err3).To(MatchError(buffer.ErrTimeout))
+			Expect(err3).To(MatchError(buffer.ErrFlushBlocked))
+			close(release)
+			_ = sut.Close()
+		})
+
+		It("tells a full buffer apart from a stalled flush on timeout", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(1).
+				WithFlusher(flusher).
+				WithInitialDelay(time.Minute).
+				WithPushTimeout(50 * time.Millisecond)
+
+			// act
+			err1 := sut.Push(1)
+			err2 := sut.Push(2)
+
+			// assert
+			Expect(err1).To(Succeed())
+			Expect(err2).To(MatchError(buffer.ErrTimeout))
+			Expect(err2).To(MatchError(buffer.ErrBufferFull))
+		})
+
+		It("fails when the buffer is closed", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher)
+
+			err := sut.Push(0)
+
+			_ = sut.Close()
+
+			// act
+			err1 := sut.Push(1)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(MatchError(buffer.ErrClosed))
+		})
+
+		It("acknowledges items once their batch has been flushed", func() {
+			// arrange
+			flusher.Err = errors.New("sink is down")
+			acks := make(chan error, 2)
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher)
+
+			// act
+			err := sut.PushWithAck(1, func(err error) { acks <- err })
+			Consistently(acks, 50*time.Millisecond).ShouldNot(Receive())
+			err1 := sut.PushWithAck(2, func(err error) { acks <- err })
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Eventually(acks).Should(Receive(MatchError("sink is down")))
+			Eventually(acks).Should(Receive(MatchError("sink is down")))
+		})
+
+		It("acknowledges requeued items only after their last attempt", func() {
+			// arrange
+			var calls atomic.Int32
+			acks := make(chan error, 1)
+			sut := buffer.New[any]().
+				WithSize(1).
+				WithFlusher(buffer.FlusherFunc[any](func([]any) error {
+					if calls.Add(1) == 1 {
+						return errors.New("sink is down")
+					}
+					return nil
+				})).
+				WithRequeueOnError(3)
+
+			// act
+			err := sut.PushWithAck(1, func(err error) { acks <- err })
+			Consistently(acks, 50*time.Millisecond).ShouldNot(Receive())
+			err1 := sut.Flush()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Eventually(acks).Should(Receive(BeNil()))
+			Expect(calls.Load()).To(BeEquivalentTo(2))
+			_ = sut.Close()
+		})
+
+		It("hands the context of an item to its ack", func() {
+			// arrange
+			type key struct{}
+			acks := make(chan context.Context, 1)
+			sut := buffer.New[any]().
+				WithSize(1).
+				WithFlusher(flusher)
+
+			ctx := context.WithValue(context.Background(), key{}, "request")
+
+			// act
+			err := sut.PushWithAckContext(ctx, 1, func(ctx context.Context, err error) {
+				Expect(err).To(Succeed())
+				acks <- ctx
+			})
+
+			// assert
+			Expect(err).To(Succeed())
+			var acked context.Context
+			Eventually(acks).Should(Receive(&acked))
+			Expect(acked.Value(key{})).To(Equal("request"))
+			_ = sut.Close()
+		})
+
+		It("skips items whose context is done when configured to", func() {
+			// arrange
+			batches := make(chan []int, 1)
+			acks := make(chan error, 2)
+			sut := buffer.New[int]().
+				WithSize(2).
+				WithFlusher(buffer.NewChannelFlusher[int](batches, 0)).
+				WithSkipCancelled()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			ack := func(ctx context.Context, err error) { acks <- err }
+
+			// act
+			err := sut.PushWithAckContext(ctx, 1, ack)
+			cancel()
+			_ = sut.PushWithAckContext(context.Background(), 2, ack)
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(batches).Should(Receive(Equal([]int{2})))
+			Eventually(acks).Should(Receive(MatchError(context.Canceled)))
+			Eventually(acks).Should(Receive(BeNil()))
+			stats, _ := sut.Stats()
+			Expect(stats.Cancelled).To(BeEquivalentTo(1))
+			_ = sut.Close()
+		})
+
+		It("skips a superseded item's context when coalescing", func() {
+			// arrange
+			batches := make(chan []string, 1)
+			acks := make(chan error, 2)
+			sut := buffer.New[string](buffer.WithLastValuePerKey(func(item string) byte { return item[0] })).
+				WithSize(2).
+				WithFlusher(buffer.NewChannelFlusher[string](batches, 0)).
+				WithSkipCancelled()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			ack := func(ctx context.Context, err error) { acks <- err }
+
+			// act
+			err := sut.PushWithAckContext(ctx, "a1", ack)
+			_ = sut.PushWithAckContext(context.Background(), "a2", ack)
+			cancel()
+			_ = sut.Push("b1")
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(batches).Should(Receive(Equal([]string{"a2", "b1"})))
+			Eventually(acks).Should(Receive(BeNil()))
+			Eventually(acks).Should(Receive(BeNil()))
+			_ = sut.Close()
+		})
+
+		It("silently drops items pushed to a closed buffer when configured to", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher).
+				WithClosedPushBehavior(buffer.SilentlyDrop)
+
+			err := sut.Push(0)
+
+			_ = sut.Close()
+			<-flusher.Done
+
+			// act
+			err1 := sut.Push(1)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Consistently(flusher.Done).ShouldNot(Receive())
+		})
+
+		It("reports the outcome of every push to the push hook", func() {
+			// arrange
+			type outcome struct {
+				item     int
+				accepted bool
+			}
+			var outcomes []outcome
+			sut := buffer.New[int]().
+				WithSize(2).
+				WithFlusher(buffer.FlusherFunc[int](func([]int) error { return nil })).
+				WithClosedPushBehavior(buffer.SilentlyDrop).
+				WithRejectZeroValue(func(item int) bool { return item == 0 }).
+				WithPushHook(func(item int, accepted bool) {
+					outcomes = append(outcomes, outcome{item, accepted})
+				})
+
+			// act
+			err := sut.Push(1)
+			err1 := sut.Push(0)
+			_ = sut.Close()
+			err2 := sut.Push(2)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(MatchError(buffer.ErrZeroValue))
+			Expect(err2).To(Succeed())
+			Expect(outcomes).To(Equal([]outcome{{1, true}, {0, false}, {2, false}}))
+		})
+	})
+
+	Context("Flushing", func() {
+		It("flushes the buffer when it fills up", func(done Done) {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(5).
+				WithFlusher(flusher)
+
+			// act
+			err := sut.Push(1)
+			_ = sut.Push(2)
+			_ = sut.Push(3)
+			_ = sut.Push(4)
+			_ = sut.Push(5)
+
+			Expect(err).To(Succeed())
+
+			// assert
+			result := <-flusher.Done
+			Expect(result.Items).To(ConsistOf(1, 2, 3, 4, 5))
+			close(done)
+		})
+
+		It("flushes exactly when the buffer reaches its size", func() {
+			// arrange
+			batches := make(chan []int, 2)
+			sut := buffer.New[int]().
+				WithSize(3).
+				WithFlusher(buffer.NewChannelFlusher[int](batches, 0))
+
+			// act
+			err := sut.Push(1)
+			_ = sut.Push(2)
+			Consistently(batches, 50*time.Millisecond).ShouldNot(Receive())
+			_ = sut.Push(3)
+			_ = sut.Push(4)
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(batches).Should(Receive(Equal([]int{1, 2, 3})))
+			Eventually(sut.Len).Should(Equal(1))
+			_ = sut.Close()
+			Expect(batches).To(Receive(Equal([]int{4})))
+		})
+
+		It("includes the item that fills the buffer in the flushed batch", func() {
+			// arrange
+			batches := make(chan []int, 1)
+			sut := buffer.New[int]().
+				WithSize(1).
+				WithFlusher(buffer.NewChannelFlusher[int](batches, 0)).
+				WithOverlappingFlush()
+
+			for i := range 10 {
+				// act
+				err := sut.Push(i)
+
+				// assert
+				Expect(err).To(Succeed())
+				Eventually(batches).Should(Receive(Equal([]int{i})))
+			}
+			_ = sut.Close()
+		})
+
+		It("flushes the buffer when the provided interval has elapsed", func(done Done) {
+			// arrange
+			interval := 3 * time.Second
+			start := time.Now()
+			sut := buffer.New[any]().
+				WithSize(5).
+				WithFlusher(flusher).
+				WithFlushInterval(interval)
+
+			// act
+			err := sut.Push(1)
+
+			// assert
+			result := <-flusher.Done
+			Expect(err).To(Succeed())
+			Expect(result.Items).To(ConsistOf(1))
+			Expect(result.Time).To(BeTemporally("~", start, interval+time.Second))
+			close(done)
+		}, 5)
+
+		It("keeps the interval on schedule across manual flushes when stable", func(done Done) {
+			// arrange
+			interval := 200 * time.Millisecond
+			start := time.Now()
+			sut := buffer.New[any]().
+				WithSize(5).
+				WithFlusher(flusher).
+				WithFlushInterval(interval).
+				WithStableInterval()
+
+			err := sut.Push(1)
+			time.Sleep(interval / 2)
+			_ = sut.Flush()
+			<-flusher.Done
+
+			// act
+			err1 := sut.Push(2)
+
+			// assert
+			result := <-flusher.Done
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(result.Items).To(ConsistOf(2))
+			Expect(result.Time).To(BeTemporally("~", start.Add(interval), interval/4))
+			close(done)
+		})
+
+		It("keeps a stable interval on schedule while the buffer is empty", func(done Done) {
+			// arrange
+			interval := 200 * time.Millisecond
+			start := time.Now()
+			sut := buffer.New[any]().
+				WithSize(5).
+				WithFlusher(flusher).
+				WithFlushInterval(interval).
+				WithStableInterval()
+
+			err := sut.Push(1)
+			time.Sleep(interval / 4)
+			_ = sut.Flush()
+			<-flusher.Done
+			time.Sleep(2*interval + interval/4 - time.Since(start))
+
+			// act
+			err1 := sut.Push(2)
+
+			// assert
+			result := <-flusher.Done
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(result.Items).To(ConsistOf(2))
+			Expect(result.Time).To(BeTemporally("~", start.Add(3*interval), interval/4))
+			close(done)
+		})
+
+		It("flushes the buffer when Flush is called", func(done Done) {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+			_ = sut.Push(2)
+
+			// act
+			err1 := sut.Flush()
+
+			// assert
+			result := <-flusher.Done
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(result.Items).To(ConsistOf(1, 2))
+			close(done)
+		})
+
+		It("flushes only items older than the cutoff when FlushOlderThan is called", func(done Done) {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+			time.Sleep(60 * time.Millisecond)
+			_ = sut.Push(2)
+
+			// act
+			err1 := sut.FlushOlderThan(30 * time.Millisecond)
+			result1 := <-flusher.Done
+			err2 := sut.Flush()
+			result2 := <-flusher.Done
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(err2).To(Succeed())
+			Expect(result1.Items).To(Equal([]any{1}))
+			Expect(result2.Items).To(Equal([]any{2}))
+			close(done)
+		})
+
+		It("does not flush when no item is older than the cutoff", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+
+			// act
+			err1 := sut.FlushOlderThan(time.Minute)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Consistently(flusher.Done).ShouldNot(Receive())
+		})
+
+		It("waits for the flush to complete when FlushAndWait is called", func() {
+			// arrange
+			flusher.Err = errors.New("sink is down")
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+
+			// act
+			err1 := sut.FlushAndWait(context.Background())
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(MatchError("sink is down"))
+			Expect(flusher.Done).To(Receive())
+		})
+
+		It("delivers the outcome of the flush when FlushAsync is called", func() {
+			// arrange
+			flusher.Err = errors.New("sink is down")
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+
+			// act
+			result := sut.FlushAsync()
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(result).Should(Receive(MatchError("sink is down")))
+			Expect(result).To(BeClosed())
+			Expect(flusher.Done).To(Receive())
+			_ = sut.Close()
+		})
+
+		It("calls the after flush function once a flush succeeds", func() {
+			// arrange
+			counts := make(chan int, 1)
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(flusher).
+				WithAfterFlush(func(count int) { counts <- count })
+
+			err := sut.Push(1)
+			_ = sut.Push(2)
+
+			// act
+			err1 := sut.FlushAndWait(context.Background())
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(counts).To(Receive(Equal(2)))
+			_ = sut.Close()
+		})
+
+		It("does not call the after flush function when a flush fails", func() {
+			// arrange
+			flusher.Err = errors.New("sink is down")
+			counts := make(chan int, 1)
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(flusher).
+				WithAfterFlush(func(count int) { counts <- count })
+
+			err := sut.Push(1)
+
+			// act
+			err1 := sut.FlushAndWait(context.Background())
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(MatchError("sink is down"))
+			Consistently(counts).ShouldNot(Receive())
+			_ = sut.Close()
+		})
+
+		It("gives every FlushAsync call a result of its own", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(buffer.FlusherFunc[any](func([]any) error { return nil }))
+
+			err := sut.Push(1)
+
+			// act
+			result1 := sut.FlushAsync()
+			result2 := sut.FlushAsync()
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(result1).Should(Receive(BeNil()))
+			Eventually(result2).Should(Receive(BeNil()))
+			Expect(result1).To(BeClosed())
+			Expect(result2).To(BeClosed())
+			_ = sut.Close()
+		})
+
+		It("fails FlushAsync when the buffer is not initialized", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(flusher)
+
+			// act
+			result := sut.FlushAsync()
+
+			// assert
+			Eventually(result).Should(Receive(MatchError(buffer.ErrNotInitialized)))
+		})
+
+		It("returns the flushed items when FlushReturn is called", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+			_ = sut.Push(2)
+
+			// act
+			items, err1 := sut.FlushReturn(context.Background())
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(items).To(Equal([]any{1, 2}))
+		})
+
+		It("returns an empty slice when FlushReturn is called on an empty buffer", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(1).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+			<-flusher.Done
+
+			// act
+			items, err1 := sut.FlushReturn(context.Background())
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(items).NotTo(BeNil())
+			Expect(items).To(BeEmpty())
+		})
+
+		It("fails when FlushAndWait does not complete before the context is done", func() {
+			// arrange
+			flusher.Func = func() { time.Sleep(100 * time.Millisecond) }
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			// act
+			err1 := sut.FlushAndWait(ctx)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(MatchError(context.DeadlineExceeded))
+		})
+
+		It("runs the barrier function once every flush completed, holding off pushes", func() {
+			// arrange
+			var mu sync.Mutex
+			var written []int
+			sut := buffer.New[int]().
+				WithSize(3).
+				WithOverlappingFlush().
+				WithFlusher(buffer.FlusherFunc[int](func(items []int) error {
+					time.Sleep(20 * time.Millisecond)
+					mu.Lock()
+					defer mu.Unlock()
+					written = append(written, items...)
+					return nil
+				}))
+
+			err := sut.Push(1)
+			_ = sut.Push(2)
+			_ = sut.Push(3)
+			_ = sut.Push(4)
+
+			// act
+			pushed := make(chan error, 1)
+			err1 := sut.Barrier(context.Background(), func() error {
+				go func() { pushed <- sut.Push(5) }()
+				Consistently(pushed).ShouldNot(Receive())
+				mu.Lock()
+				defer mu.Unlock()
+				Expect(written).To(ConsistOf(1, 2, 3, 4))
+				return nil
+			})
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Eventually(pushed).Should(Receive(Succeed()))
+			_ = sut.Close()
+		})
+
+		It("skips the barrier function when the flush fails", func() {
+			// arrange
+			flusher.Err = errors.New("sink is down")
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+			called := false
+
+			// act
+			err1 := sut.Barrier(context.Background(), func() error {
+				called = true
+				return nil
+			})
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(MatchError("sink is down"))
+			Expect(called).To(BeFalse())
+			_ = sut.Close()
+		})
+
+		It("returns the error of the barrier function", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+
+			// act
+			err1 := sut.Barrier(context.Background(), func() error { return errors.New("migration failed") })
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(MatchError("migration failed"))
+			Expect(flusher.Done).To(Receive())
+			_ = sut.Close()
+		})
+
+		It("waits for the interval instead of flushing a full buffer when disabled", func() {
+			// arrange
+			batches := make(chan []int, 2)
+			sut := buffer.New[int]().
+				WithSize(2).
+				WithFlusher(buffer.NewChannelFlusher[int](batches, 0)).
+				WithFlushInterval(100 * time.Millisecond).
+				WithFlushOnFull(false)
+
+			start := time.Now()
+
+			// act
+			err := sut.Push(1)
+			_ = sut.Push(2)
+			err1 := sut.Push(3)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(time.Since(start)).To(BeNumerically(">=", 90*time.Millisecond))
+			Expect(batches).To(Receive(Equal([]int{1, 2})))
+			Eventually(batches).Should(Receive(Equal([]int{3})))
+			_ = sut.Close()
+		})
+
+		It("pauses the interval while the buffer is empty", func() {
+			// arrange
+			batches := make(chan []int, 2)
+			sut := buffer.New[int]().
+				WithSize(10).
+				WithFlusher(buffer.NewChannelFlusher[int](batches, 0)).
+				WithFlushInterval(100 * time.Millisecond)
+
+			err := sut.Push(1)
+			Eventually(batches).Should(Receive(Equal([]int{1})))
+			Consistently(batches, 150*time.Millisecond).ShouldNot(Receive())
+
+			// act
+			start := time.Now()
+			err1 := sut.Push(2)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Eventually(batches).Should(Receive(Equal([]int{2})))
+			Expect(time.Since(start)).To(BeNumerically(">=", 90*time.Millisecond))
+			_ = sut.Close()
+		})
+
+		It("flushes every buffer sharing a closed flush trigger", func() {
+			// arrange
+			trigger := make(chan struct{})
+			batches1, batches2 := make(chan []int, 1), make(chan []int, 1)
+			sut1 := buffer.New[int]().
+				WithSize(10).
+				WithFlusher(buffer.NewChannelFlusher[int](batches1, 0)).
+				WithFlushTrigger(trigger)
+			sut2 := buffer.New[int]().
+				WithSize(10).
+				WithFlusher(buffer.NewChannelFlusher[int](batches2, 0)).
+				WithFlushTrigger(trigger)
+
+			err1 := sut1.Push(1)
+			err2 := sut2.Push(2)
+
+			// act
+			close(trigger)
+
+			// assert
+			Expect(err1).To(Succeed())
+			Expect(err2).To(Succeed())
+			Eventually(batches1).Should(Receive(Equal([]int{1})))
+			Eventually(batches2).Should(Receive(Equal([]int{2})))
+			Expect(sut1.Push(3)).To(Succeed())
+			Consistently(batches1, 50*time.Millisecond).ShouldNot(Receive())
+			_ = sut1.Close()
+			_ = sut2.Close()
+		})
+
+		It("collapses calls to Flush within the debounce period", func() {
+			// arrange
+			batches := make(chan []int, 5)
+			sut := buffer.New[int]().
+				WithSize(10).
+				WithFlusher(buffer.NewChannelFlusher[int](batches, 0)).
+				WithFlushDebounce(50 * time.Millisecond)
+
+			// act
+			var err error
+			for i := range 5 {
+				err = errors.Join(err, sut.Push(i), sut.Flush())
+			}
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(batches).NotTo(Receive())
+			Eventually(batches).Should(Receive(Equal([]int{0, 1, 2, 3, 4})))
+			Consistently(batches, 100*time.Millisecond).ShouldNot(Receive())
+			_ = sut.Close()
+		})
+
+		It("fails when FlushWithin does not complete in time", func() {
+			// arrange
+			flusher.Func = func() { time.Sleep(100 * time.Millisecond) }
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+
+			// act
+			err1 := sut.FlushWithin(10 * time.Millisecond)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(MatchError(buffer.ErrTimeout))
+			Eventually(flusher.Done).Should(Receive())
+			Expect(sut.Push(2)).To(Succeed())
+			Expect(sut.FlushWithin(time.Second)).To(Succeed())
+		})
+
+		It("hands the flusher a copy of the batch when CopyOnFlush is enabled", func(done Done) {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(flusher).
+				WithCopyOnFlush()
+
+			err := sut.Push(1)
+			_ = sut.Push(2)
+
+			// act
+			err1 := sut.Flush()
+
+			// assert
+			result := <-flusher.Done
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(result.Items).To(Equal([]any{1, 2}))
+			Expect(cap(result.Items)).To(Equal(2))
+			close(done)
+		})
+
+		It("fails when the buffer is not initialized", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher)
+
+			// act
+			err := sut.Flush()
+
+			// assert
+			Expect(err).To(MatchError(buffer.ErrNotInitialized))
+		})
+
+		It("fails when Flush cannot execute in a timely fashion", func() {
+			// arrange
+			flusher.Func = func() { time.Sleep(3 * time.Second) }
+			sut := buffer.New[any]().
+				WithSize(1).
+				WithFlusher(flusher).
+				WithFlushTimeout(time.Second)
+
+			err := sut.Push(1)
+
+			// act
+			err1 := sut.Flush()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(MatchError(buffer.ErrTimeout))
+		})
+
+		It("scales the flush timeout with the number of pending items", func() {
+			// arrange
+			flusher.Func = func() { time.Sleep(300 * time.Millisecond) }
+			sut := buffer.New[any]().
+				WithSize(5).
+				WithFlusher(flusher).
+				WithFlushTimeout(50 * time.Millisecond).
+				WithPerItemFlushTimeout(200 * time.Millisecond)
+
+			err := sut.Push(1)
+			_ = sut.Push(2)
+			_ = sut.Push(3)
+
+			// act
+			err1 := sut.Flush()
+			err2 := sut.Flush()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(err2).To(Succeed())
+		})
+
+		It("flushes every batch to the flusher picked by the selector", func(done Done) {
+			// arrange
+			odd, even := NewMockFlusher[int](), NewMockFlusher[int]()
+			sut := buffer.New[int]().
+				WithSize(1).
+				WithFlusherSelector(func(items []int) buffer.Flusher[int] {
+					if items[0]%2 == 0 {
+						return even
+					}
+					return odd
+				})
+
+			// act
+			err := sut.Push(1)
+			_ = sut.Push(2)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect((<-odd.Done).Items).To(Equal([]int{1}))
+			Expect((<-even.Done).Items).To(Equal([]int{2}))
+			close(done)
+		})
+
+		It("samples items deterministically with a seeded source", func() {
+			// arrange
+			sample := func() ([]int, buffer.Stats) {
+				batches := make(chan []int, 1)
+				sut := buffer.New[int]().
+					WithSize(100).
+					WithFlusher(buffer.NewChannelFlusher[int](batches, 0)).
+					WithSampleRate(0.5).
+					WithRandSource(rand.NewSource(42))
+
+				for i := range 100 {
+					_ = sut.Push(i)
+				}
+				batch := <-batches
+				stats, _ := sut.Stats()
+				_ = sut.Close()
+
+				return batch, stats
+			}
+
+			// act
+			batch, stats := sample()
+			batch1, _ := sample()
+
+			// assert
+			Expect(batch).To(Equal(batch1))
+			Expect(len(batch)).To(BeNumerically("~", 50, 20))
+			Expect(stats.Sampled).To(BeEquivalentTo(100 - len(batch)))
+			Expect(stats.Flushed).To(BeEquivalentTo(len(batch)))
+		})
+
+		It("writes every group of a batch with a separate call", func() {
+			// arrange
+			batches := make(chan []string, 3)
+			sut := buffer.New[string](buffer.WithFlushGroupBy(func(item string) byte { return item[0] })).
+				WithSize(5).
+				WithFlusher(buffer.NewChannelFlusher[string](batches, 0))
+
+			// act
+			err := sut.Push("a1")
+			_ = sut.Push("b1")
+			_ = sut.Push("a2")
+			_ = sut.Push("c1")
+			_ = sut.Push("b2")
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(batches).Should(Receive(Equal([]string{"a1", "a2"})))
+			Eventually(batches).Should(Receive(Equal([]string{"b1", "b2"})))
+			Eventually(batches).Should(Receive(Equal([]string{"c1"})))
+			_ = sut.Close()
+		})
+
+		It("requeues only the groups that were not written", func() {
+			// arrange
+			var (
+				mu     sync.Mutex
+				calls  [][]int
+				failed bool
+			)
+			writes := func() [][]int {
+				mu.Lock()
+				defer mu.Unlock()
+				return append([][]int(nil), calls...)
+			}
+			sut := buffer.New[int](buffer.WithFlushGroupBy(func(item int) int { return item % 2 })).
+				WithSize(4).
+				WithFlusher(buffer.FlusherFunc[int](func(items []int) error {
+					mu.Lock()
+					defer mu.Unlock()
+					calls = append(calls, append([]int(nil), items...))
+					if items[0]%2 == 0 && !failed {
+						failed = true
+						return errors.New("sink failed")
+					}
+					return nil
+				})).
+				WithRequeueOnError(3)
+
+			// act
+			var err error
+			for i := 1; i <= 4; i++ {
+				err = errors.Join(err, sut.Push(i))
+			}
+			Eventually(writes).Should(HaveLen(2))
+			err1 := sut.Flush()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Eventually(writes).Should(Equal([][]int{{1, 3}, {2, 4}, {2, 4}}))
+			_ = sut.Close()
+		})
+
+		It("acknowledges the groups that were written when another group fails", func() {
+			// arrange
+			errSink := errors.New("sink failed")
+			type ack struct {
+				item int
+				err  error
+			}
+			acks := make(chan ack, 4)
+			sut := buffer.New[int](buffer.WithFlushGroupBy(func(item int) int { return item % 2 })).
+				WithSize(4).
+				WithFlusher(buffer.FlusherFunc[int](func(items []int) error {
+					if items[0]%2 == 0 {
+						return errSink
+					}
+					return nil
+				}))
+
+			// act
+			var err error
+			for i := 1; i <= 4; i++ {
+				err = errors.Join(err, sut.PushWithAck(i, func(err error) { acks <- ack{i, err} }))
+			}
+
+			// assert
+			Expect(err).To(Succeed())
+			received := map[int]error{}
+			for range 4 {
+				var a ack
+				Eventually(acks).Should(Receive(&a))
+				received[a.item] = a.err
+			}
+			Expect(received[1]).To(BeNil())
+			Expect(received[3]).To(BeNil())
+			Expect(received[2]).To(MatchError(errSink))
+			Expect(received[4]).To(MatchError(errSink))
+			_ = sut.Close()
+		})
+
+		It("writes a batch in chunks of at most the max write batch", func() {
+			// arrange
+			batches := make(chan []int, 3)
+			sut := buffer.New[int]().
+				WithSize(5).
+				WithFlusher(buffer.NewChannelFlusher[int](batches, 0)).
+				WithMaxWriteBatch(2)
+
+			// act
+			var err error
+			for i := 1; i <= 5; i++ {
+				err = errors.Join(err, sut.Push(i))
+			}
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(batches).Should(Receive(Equal([]int{1, 2})))
+			Eventually(batches).Should(Receive(Equal([]int{3, 4})))
+			Eventually(batches).Should(Receive(Equal([]int{5})))
+			_ = sut.Close()
+		})
+
+		It("never hands the flusher more than the buffer size in strict size mode", func() {
+			// arrange
+			batches := make(chan []int, 3)
+			sut := buffer.New[int]().
+				WithSize(2).
+				WithFlusher(buffer.NewChannelFlusher[int](batches, 0)).
+				WithBatchTransform(func(items []int) []int {
+					// expand every item into two
+					var expanded []int
+					for _, item := range items {
+						expanded = append(expanded, item, -item)
+					}
+					return expanded
+				}).
+				WithStrictSize()
+
+			// act
+			err := sut.Push(1)
+			_ = sut.Push(2)
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(batches).Should(Receive(Equal([]int{1, -1})))
+			Eventually(batches).Should(Receive(Equal([]int{2, -2})))
+			_ = sut.Close()
+		})
+
+		It("fails the remaining chunks when a chunk cannot be written", func() {
+			// arrange
+			errSink := errors.New("sink failed")
+			var written [][]int
+			failed := make(chan []int, 1)
+			sut := buffer.New[int]().
+				WithSize(5).
+				WithFlusher(buffer.FlusherFunc[int](func(items []int) error {
+					if items[0] == 3 {
+						return errSink
+					}
+					written = append(written, append([]int(nil), items...))
+					return nil
+				})).
+				WithErrorHandler(func(err error, items []int) { failed <- items }).
+				WithMaxWriteBatch(2)
+
+			// act
+			var err error
+			for i := 1; i <= 5; i++ {
+				err = errors.Join(err, sut.Push(i))
+			}
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(failed).Should(Receive(Equal([]int{3, 4, 5})))
+			Expect(sut.Close()).To(Succeed())
+			Expect(written).To(Equal([][]int{{1, 2}}))
+		})
+
+		It("requeues only the chunks that were not written", func() {
+			// arrange
+			var (
+				mu     sync.Mutex
+				calls  [][]int
+				failed bool
+			)
+			writes := func() [][]int {
+				mu.Lock()
+				defer mu.Unlock()
+				return append([][]int(nil), calls...)
+			}
+			acks := make(chan error, 4)
+			sut := buffer.New[int]().
+				WithSize(4).
+				WithFlusher(buffer.FlusherFunc[int](func(items []int) error {
+					mu.Lock()
+					defer mu.Unlock()
+					calls = append(calls, append([]int(nil), items...))
+					if items[0] == 3 && !failed {
+						failed = true
+						return errors.New("sink failed")
+					}
+					return nil
+				})).
+				WithMaxWriteBatch(2).
+				WithRequeueOnError(3)
+
+			// act
+			var err error
+			for i := 1; i <= 4; i++ {
+				err = errors.Join(err, sut.PushWithAck(i, func(err error) { acks <- err }))
+			}
+			Eventually(writes).Should(HaveLen(2))
+			err1 := sut.Flush()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Eventually(writes).Should(Equal([][]int{{1, 2}, {3, 4}, {3, 4}}))
+			for range 4 {
+				Eventually(acks).Should(Receive(BeNil()))
+			}
+			_ = sut.Close()
+		})
+
+		It("hands the push time of every item to a timestamped flusher", func() {
+			// arrange
+			batches := make(chan []buffer.TimestampedItem[string], 2)
+			sut := buffer.New[string](buffer.WithFlushGroupBy(func(item string) byte { return item[0] })).
+				WithSize(3).
+				WithTimestamps(buffer.TimestampedFlusherFunc[string](func(items []buffer.TimestampedItem[string]) error {
+					batches <- items
+					return nil
+				}))
+
+			start := time.Now()
+
+			// act
+			err := sut.Push("a1")
+			_ = sut.Push("b1")
+			_ = sut.Push("a2")
+
+			// assert
+			Expect(err).To(Succeed())
+			var a, b []buffer.TimestampedItem[string]
+			Eventually(batches).Should(Receive(&a))
+			Eventually(batches).Should(Receive(&b))
+			Expect(a).To(HaveLen(2))
+			Expect(a[0].Item).To(Equal("a1"))
+			Expect(a[1].Item).To(Equal("a2"))
+			Expect(b).To(HaveLen(1))
+			Expect(b[0].Item).To(Equal("b1"))
+			Expect(a[0].PushedAt).To(BeTemporally(">=", start))
+			Expect(b[0].PushedAt).To(BeTemporally(">=", a[0].PushedAt))
+			Expect(a[1].PushedAt).To(BeTemporally(">=", b[0].PushedAt))
+			_ = sut.Close()
+		})
+
+		It("reverses every batch when the flush order is LIFO", func() {
+			// arrange
+			collector := buffer.NewCollectFlusher[int]()
+			sut := buffer.New[int]().
+				WithSize(3).
+				WithFlusher(collector).
+				WithFlushOrder(buffer.LIFO)
+
+			// act
+			var err error
+			for i := 1; i <= 5; i++ {
+				err = errors.Join(err, sut.Push(i))
+			}
+			_ = sut.Close()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(collector.Batches()).To(Equal([][]int{{3, 2, 1}, {5, 4}}))
+		})
+
+		It("writes the batch returned by the batch transform", func() {
+			// arrange
+			batches := make(chan []int, 1)
+			sut := buffer.New[int]().
+				WithSize(3).
+				WithFlusher(buffer.NewChannelFlusher[int](batches, 0)).
+				WithBatchTransform(func(items []int) []int {
+					sort.Ints(items)
+					return items
+				})
+
+			// act
+			err := sut.Push(3)
+			_ = sut.Push(1)
+			_ = sut.Push(2)
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(batches).Should(Receive(Equal([]int{1, 2, 3})))
+			_ = sut.Close()
+		})
+
+		It("skips the write when the batch transform returns an empty batch", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(1).
+				WithFlusher(flusher).
+				WithBatchTransform(func(items []any) []any { return nil })
+
+			// act
+			err := sut.Push(1)
+
+			// assert
+			Expect(err).To(Succeed())
+			Consistently(flusher.Done).ShouldNot(Receive())
+			_ = sut.Close()
+		})
+
+		It("drops items whose key was flushed within the dedup window", func() {
+			// arrange
+			batches := make(chan []string, 3)
+			window := 100 * time.Millisecond
+			sut := buffer.New[string](buffer.WithDedupWindow(func(item string) string { return item }, window)).
+				WithSize(2).
+				WithFlusher(buffer.NewChannelFlusher[string](batches, 0))
+
+			// act
+			err := sut.Push("a")
+			_ = sut.Push("b")
+			_ = sut.Push("a")
+			_ = sut.Push("c")
+			time.Sleep(2 * window)
+			_ = sut.Push("a")
+			_ = sut.Push("a")
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(batches).Should(Receive(Equal([]string{"a", "b"})))
+			Eventually(batches).Should(Receive(Equal([]string{"c"})))
+			Eventually(batches).Should(Receive(Equal([]string{"a"})))
+			stats, _ := sut.Stats()
+			Expect(stats.Deduplicated).To(BeEquivalentTo(2))
+			_ = sut.Close()
+		})
+
+		It("keeps only the latest item per key", func() {
+			// arrange
+			type state struct {
+				Key   string
+				Value int
+			}
+			batches := make(chan []state, 2)
+			sut := buffer.New[state](buffer.WithLastValuePerKey(func(item state) string { return item.Key })).
+				WithSize(2).
+				WithFlusher(buffer.NewChannelFlusher[state](batches, 0))
+
+			// act
+			err := sut.Push(state{"a", 1})
+			_ = sut.Push(state{"a", 2})
+			_ = sut.Push(state{"a", 3})
+			_ = sut.Push(state{"b", 1})
+			_ = sut.Push(state{"a", 4})
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(batches).Should(Receive(Equal([]state{{"a", 3}, {"b", 1}})))
+			Expect(sut.Flush()).To(Succeed())
+			Eventually(batches).Should(Receive(Equal([]state{{"a", 4}})))
+			stats, _ := sut.Stats()
+			Expect(stats.Pushed).To(BeEquivalentTo(5))
+			Expect(stats.Coalesced).To(BeEquivalentTo(2))
+			_ = sut.Close()
+		})
+
+		It("flushes once the buffer holds items for enough distinct keys", func() {
+			// arrange
+			batches := make(chan []string, 2)
+			sut := buffer.New[string](buffer.WithDistinctKeyTrigger(func(item string) byte { return item[0] }, 2)).
+				WithSize(10).
+				WithFlusher(buffer.NewChannelFlusher[string](batches, 0))
+
+			// act
+			err := sut.Push("a1")
+			_ = sut.Push("a2")
+			_ = sut.Push("a3")
+			Consistently(batches, 50*time.Millisecond).ShouldNot(Receive())
+			_ = sut.Push("b1")
+			_ = sut.Push("c1")
+			_ = sut.Push("c2")
+			_ = sut.Push("a4")
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(batches).Should(Receive(Equal([]string{"a1", "a2", "a3", "b1"})))
+			Eventually(batches).Should(Receive(Equal([]string{"c1", "c2", "a4"})))
+			_ = sut.Close()
+		})
+
+		It("acknowledges superseded items as written", func() {
+			// arrange
+			sut := buffer.New[int](buffer.WithLastValuePerKey(func(item int) int { return item % 2 })).
+				WithSize(2).
+				WithFlusher(buffer.FlusherFunc[int](func([]int) error { return nil }))
+
+			// act
+			done := make(chan error, 1)
+			err := sut.PushWithAck(1, func(err error) { done <- err })
+			_ = sut.Push(3)
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(done).Should(Receive(BeNil()))
+			Expect(sut.Len()).To(Equal(1))
+			_ = sut.Close()
+		})
+
+		It("hands the context passed to FlushContext to a context-aware flusher", func() {
+			// arrange
+			type key struct{}
+			contexts := make(chan context.Context, 2)
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithContextFlusher(buffer.ContextFlusherFunc[any](func(ctx context.Context, items []any) error {
+					contexts <- ctx
+					return nil
+				}))
+
+			ctx := context.WithValue(context.Background(), key{}, "request")
+			err := sut.Push(1)
+
+			// act
+			err1 := sut.FlushContext(ctx)
+			_ = sut.Push(2)
+			_ = sut.Push(3)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			var received context.Context
+			Eventually(contexts).Should(Receive(&received))
+			Expect(received.Value(key{})).To(Equal("request"))
+			Eventually(contexts).Should(Receive(&received))
+			Expect(received.Value(key{})).To(BeNil())
+			_ = sut.Close()
+		})
+
+		It("hands the contexts of the pushes to a context-aware flusher", func() {
+			// arrange
+			type key struct{}
+			contexts := make(chan []context.Context, 1)
+			sut := buffer.New[int]().
+				WithSize(2).
+				WithContextFlusher(buffer.ContextFlusherFunc[int](func(ctx context.Context, items []int) error {
+					contexts <- buffer.ItemContextsFromContext(ctx)
+					return nil
+				}))
+
+			ctx := context.WithValue(context.Background(), key{}, "push")
+
+			// act
+			err := sut.PushWithAckContext(ctx, 1, nil)
+			_ = sut.Push(2)
+
+			// assert
+			Expect(err).To(Succeed())
+			var received []context.Context
+			Eventually(contexts).Should(Receive(&received))
+			Expect(received).To(HaveLen(2))
+			Expect(received[0].Value(key{})).To(Equal("push"))
+			Expect(received[1]).To(BeNil())
+			_ = sut.Close()
+		})
+
+		It("coalesces flushes requested while a flush is pending", func() {
+			// arrange
+			var writes atomic.Int32
+			gate := make(chan struct{})
+			sut := buffer.New[any]().
+				WithSize(1).
+				WithFlusher(buffer.FlusherFunc[any](func([]any) error {
+					writes.Add(1)
+					<-gate
+					return nil
+				}))
+
+			err := sut.Push(1)
+			pending := make(chan error, 1)
+			go func() { pending <- sut.Flush() }()
+			time.Sleep(20 * time.Millisecond)
+
+			// act
+			merged := make(chan error, 2)
+			go func() { merged <- sut.Flush() }()
+			go func() { merged <- sut.Flush() }()
+			time.Sleep(20 * time.Millisecond)
+			close(gate)
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(pending).Should(Receive(Succeed()))
+			Eventually(merged).Should(Receive(Succeed()))
+			Eventually(merged).Should(Receive(Succeed()))
+			Expect(sut.Close()).To(Succeed())
+			Expect(writes.Load()).To(BeEquivalentTo(1))
+		})
+
+		It("does not coalesce a flush into one that has already been taken", func() {
+			// arrange
+			var flushed atomic.Int32
+			sut := buffer.New[int]().
+				WithSize(1000).
+				WithFlusher(buffer.FlusherFunc[int](func(items []int) error {
+					flushed.Add(int32(len(items)))
+					return nil
+				}))
+
+			err := sut.Push(-1)
+
+			// act
+			var wg sync.WaitGroup
+			for i := range 16 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := range 200 {
+						_ = sut.Push(i*200 + j)
+						_ = sut.Flush()
+					}
+				}()
+			}
+			wg.Wait()
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(flushed.Load).Should(BeEquivalentTo(3201))
+			_ = sut.Close()
+		})
+
+		It("hands the outcome of a pending flush to the calls coalesced into it", func() {
+			// arrange
+			gate := make(chan struct{})
+			sut := buffer.New[any]().
+				WithSize(1).
+				WithFlushTimeout(100 * time.Millisecond).
+				WithFlusher(buffer.FlusherFunc[any](func([]any) error {
+					<-gate
+					return nil
+				}))
+
+			err := sut.Push(1)
+			pending := make(chan error, 1)
+			go func() { pending <- sut.Flush() }()
+			time.Sleep(20 * time.Millisecond)
+
+			// act
+			err1 := sut.Flush()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(MatchError(buffer.ErrTimeout))
+			Eventually(pending).Should(Receive(MatchError(buffer.ErrTimeout)))
+			close(gate)
+			_ = sut.Close()
+		})
+
+		It("suppresses flushing until the initial delay has elapsed", func(done Done) {
+			// arrange
+			delay := 200 * time.Millisecond
+			start := time.Now()
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher).
+				WithInitialDelay(delay)
+
+			// act
+			err := sut.Push(1)
+			_ = sut.Push(2)
+			err1 := sut.Flush()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Consistently(flusher.Done, delay/2).ShouldNot(Receive())
+			result := <-flusher.Done
+			Expect(result.Items).To(ConsistOf(1, 2))
+			Expect(result.Time).To(BeTemporally(">=", start.Add(delay)))
+			close(done)
+		})
+
+		It("fails when the buffer is closed", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+
+			_ = sut.Close()
+
+			// act
+			err1 := sut.Flush()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(MatchError(buffer.ErrClosed))
+		})
+	})
+
+	Context("Slow flushers", func() {
+		var (
+			mu      sync.Mutex
+			active  atomic.Int32
+			maximum atomic.Int32
+			slow    buffer.FlusherFunc[any]
+		)
+
+		BeforeEach(func() {
+			active.Store(0)
+			maximum.Store(0)
+			slow = func(items []any) error {
+				mu.Lock()
+				if n := active.Add(1); n > maximum.Load() {
+					maximum.Store(n)
+				}
+				mu.Unlock()
+				defer active.Add(-1)
+
+				time.Sleep(100 * time.Millisecond)
+				return nil
+			}
+		})
+
+		It("blocks intake while a flush slower than the interval is running", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(10).
+				WithFlushInterval(20 * time.Millisecond).
+				WithFlusher(slow)
+
+			err := sut.Push(1)
+			Eventually(active.Load).Should(Equal(int32(1)))
+
+			// act
+			start := time.Now()
+			err1 := sut.Push(2)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(time.Since(start)).To(BeNumerically(">", 50*time.Millisecond))
+			Expect(sut.Close()).To(Succeed())
+			Expect(maximum.Load()).To(Equal(int32(1)))
+		})
+
+		It("starts interval flushes while a previous one is running when overlapping", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(10).
+				WithFlushInterval(20 * time.Millisecond).
+				WithFlusher(slow).
+				WithOverlappingFlush()
+
+			err := sut.Push(1)
+			Eventually(active.Load).Should(Equal(int32(1)))
+
+			// act
+			start := time.Now()
+			err1 := sut.Push(2)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(time.Since(start)).To(BeNumerically("<", 50*time.Millisecond))
+			Eventually(maximum.Load).Should(Equal(int32(2)))
+			Expect(sut.Close()).To(Succeed())
+			Expect(active.Load()).To(Equal(int32(0)))
+		})
+
+		It("never writes concurrently when flushes are exclusive", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(1).
+				WithFlusher(slow).
+				WithOverlappingFlush().
+				WithExclusiveFlush()
+
+			// act
+			for i := range 5 {
+				Expect(sut.Push(i)).To(Succeed())
+			}
+
+			// assert
+			Expect(sut.Close()).To(Succeed())
+			Expect(maximum.Load()).To(Equal(int32(1)))
+		})
+	})
+
+	Context("In-flight batches", func() {
+		It("blocks once the maximum number of batches is in flight", func() {
+			// arrange
+			gate := make(chan struct{})
+			sut := buffer.New[int]().
+				WithSize(1).
+				WithFlusher(buffer.FlusherFunc[int](func([]int) error {
+					<-gate
+					return nil
+				})).
+				WithPushTimeout(100 * time.Millisecond).
+				WithOverlappingFlush().
+				WithMaxInFlightBatches(2)
+
+			err := sut.Push(1)
+			_ = sut.Push(2)
+			Eventually(func() int {
+				stats, _ := sut.Stats()
+				return stats.InFlight
+			}).Should(Equal(2))
+
+			// act
+			err1 := sut.Push(3)
+			err2 := sut.Push(4)
+			close(gate)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(err2).To(MatchError(buffer.ErrTimeout))
+			Expect(sut.Close()).To(Succeed())
+		})
+	})
+
+	Context("Ordered concurrency", func() {
+		It("serializes batches per key and parallelizes across keys", func() {
+			// arrange
+			var (
+				mu      sync.Mutex
+				active  int
+				maximum int
+				written = map[string][]string{}
+			)
+			sut := buffer.New[string](
+				buffer.WithOrderedConcurrency(func(items []string) string { return items[0][:1] }),
+			).
+				WithSize(1).
+				WithFlusher(buffer.FlusherFunc[string](func(items []string) error {
+					mu.Lock()
+					active++
+					maximum = max(maximum, active)
+					mu.Unlock()
+
+					time.Sleep(50 * time.Millisecond)
+
+					mu.Lock()
+					defer mu.Unlock()
+					active--
+					for _, item := range items {
+						written[item[:1]] = append(written[item[:1]], item)
+					}
+					return nil
+				}))
+
+			// act
+			for i := 0; i < 3; i++ {
+				Expect(sut.Push(fmt.Sprintf("a%d", i))).To(Succeed())
+				Expect(sut.Push(fmt.Sprintf("b%d", i))).To(Succeed())
+			}
+			err := sut.Close()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(maximum).To(Equal(2))
+			Expect(written).To(Equal(map[string][]string{
+				"a": {"a0", "a1", "a2"},
+				"b": {"b0", "b1", "b2"},
+			}))
+		})
+	})
+
+	Context("Memory limit", func() {
+		It("flushes early when a push would exceed the memory limit", func() {
+			// arrange
+			strings := NewMockFlusher[string]()
+			sut := buffer.New[string]().
+				WithSize(10).
+				WithFlusher(strings).
+				WithMemoryLimit(5, func(item string) int { return len(item) }, nil)
+
+			// act
+			err := sut.Push("abc")
+			_ = sut.Push("de")
+			_ = sut.Push("f")
+
+			// assert
+			Expect(err).To(Succeed())
+			var result *WriteCall[string]
+			Eventually(strings.Done).Should(Receive(&result))
+			Expect(result.Items).To(Equal([]string{"abc", "de"}))
+			Expect(sut.Len()).To(Equal(1))
+		})
+
+		It("evicts the oldest items when configured to", func() {
+			// arrange
+			var evicted []string
+			strings := NewMockFlusher[string]()
+			sut := buffer.New[string]().
+				WithSize(10).
+				WithFlusher(strings).
+				WithMemoryLimit(5, func(item string) int { return len(item) }, func(item string) {
+					evicted = append(evicted, item)
+				}).
+				WithMemoryPolicy(buffer.MemoryEvict)
+
+			// act
+			err := sut.Push("abc")
+			_ = sut.Push("de")
+			_ = sut.Push("fg")
+			err1 := sut.Close()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			var result *WriteCall[string]
+			Expect(strings.Done).To(Receive(&result))
+			Expect(result.Items).To(Equal([]string{"de", "fg"}))
+			Expect(evicted).To(Equal([]string{"abc"}))
+		})
+	})
+
+	Context("Size schedule", func() {
+		var (
+			now      atomic.Int64
+			clock    func() time.Time
+			schedule func(time.Time) uint
+		)
+
+		BeforeEach(func() {
+			now.Store(time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC).UnixNano())
+			clock = func() time.Time { return time.Unix(0, now.Load()) }
+			// small batches in the morning, full ones afterwards
+			schedule = func(t time.Time) uint {
+				if t.Hour() < 12 {
+					return 2
+				}
+				return 0
+			}
+		})
+
+		It("flushes at the threshold of the current time", func() {
+			// arrange
+			batches := make(chan []int, 2)
+			sut := buffer.New[int]().
+				WithSize(4).
+				WithFlusher(buffer.NewChannelFlusher[int](batches, 0)).
+				WithSizeSchedule(schedule).
+				WithClock(clock)
+
+			// act
+			err := sut.Push(1)
+			_ = sut.Push(2)
+			morning := <-batches
+
+			now.Add(int64(4 * time.Hour))
+			for i := 3; i <= 5; i++ {
+				_ = sut.Push(i)
+			}
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(morning).To(Equal([]int{1, 2}))
+			Consistently(batches).ShouldNot(Receive())
+			Expect(sut.Push(6)).To(Succeed())
+			Eventually(batches).Should(Receive(Equal([]int{3, 4, 5, 6})))
+			_ = sut.Close()
+		})
+
+		It("flushes right away when the threshold drops below the buffered items", func() {
+			// arrange
+			batches := make(chan []int, 2)
+			now.Add(int64(4 * time.Hour))
+			sut := buffer.New[int]().
+				WithSize(4).
+				WithFlusher(buffer.NewChannelFlusher[int](batches, 0)).
+				WithSizeSchedule(schedule).
+				WithClock(clock)
+
+			err := sut.Push(1)
+			_ = sut.Push(2)
+			_ = sut.Push(3)
+
+			// act
+			now.Add(int64(20 * time.Hour))
+			_ = sut.Push(4)
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(batches).Should(Receive(Equal([]int{1, 2, 3})))
+			Expect(sut.Len()).To(Equal(1))
+			Expect(sut.Push(5)).To(Succeed())
+			Eventually(batches).Should(Receive(Equal([]int{4, 5})))
+			_ = sut.Close()
+		})
+	})
+
+	Context("Error handling", func() {
+		It("passes write errors to the error handler", func() {
+			// arrange
+			failed := make(chan error, 1)
+			flusher.Err = errors.New("sink is down")
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher).
+				WithErrorHandler(func(err error, items []any) { failed <- err })
+
+			// act
+			err := sut.Push(1)
+			_ = sut.Push(2)
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(failed).Should(Receive(MatchError("sink is down")))
+		})
+
+		It("routes batches rejected by the batch validator to the error handler", func() {
+			// arrange
+			type failure struct {
+				err   error
+				items []any
+			}
+			failed := make(chan failure, 1)
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher).
+				WithBatchValidator(func(items []any) error { return errors.New("mixed tenants") }).
+				WithErrorHandler(func(err error, items []any) { failed <- failure{err, items} })
+
+			// act
+			err := sut.Push(1)
+			_ = sut.Push(2)
+
+			// assert
+			Expect(err).To(Succeed())
+			var result failure
+			Eventually(failed).Should(Receive(&result))
+			Expect(result.err).To(MatchError(buffer.ErrInvalidBatch))
+			Expect(result.items).To(Equal([]any{1, 2}))
+			Consistently(flusher.Done).ShouldNot(Receive())
+		})
+
+		It("routes batches without a selected flusher to the error handler", func() {
+			// arrange
+			failed := make(chan error, 1)
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusherSelector(func(items []any) buffer.Flusher[any] { return nil }).
+				WithErrorHandler(func(err error, items []any) { failed <- err })
+
+			// act
+			err := sut.Push(1)
+			_ = sut.Push(2)
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(failed).Should(Receive(MatchError(buffer.ErrNoFlusher)))
+		})
+
+		It("requeues a failed batch ahead of newer items", func() {
+			// arrange
+			var calls int
+			batches := make(chan []int, 2)
+			sut := buffer.New[int]().
+				WithSize(3).
+				WithFlusher(buffer.FlusherFunc[int](func(items []int) error {
+					calls++
+					if calls == 1 {
+						return errors.New("sink is down")
+					}
+					batches <- append([]int(nil), items...)
+					return nil
+				})).
+				WithRequeueOnError(3)
+
+			err := sut.Push(1)
+			_ = sut.Push(2)
+			_ = sut.Flush()
+
+			// act
+			err1 := sut.Push(3)
+			err2 := sut.Close()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(err2).To(Succeed())
+			Expect(batches).To(Receive(Equal([]int{1, 2, 3})))
+		})
+
+		It("fails requeued items once they run out of attempts", func() {
+			// arrange
+			type failure struct {
+				err   error
+				items []any
+			}
+			failed := make(chan failure, 1)
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(buffer.FlusherFunc[any](func(items []any) error { return errors.New("sink is down") })).
+				WithRequeueOnError(2).
+				WithErrorHandler(func(err error, items []any) { failed <- failure{err, items} })
+
+			err := sut.Push(1)
+
+			// act
+			err1 := sut.Flush()
+			Consistently(failed, 50*time.Millisecond).ShouldNot(Receive())
+			err2 := sut.Flush()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(err2).To(Succeed())
+			var result failure
+			Eventually(failed).Should(Receive(&result))
+			Expect(result.err).To(MatchError("sink is down"))
+			Expect(result.items).To(Equal([]any{1}))
+			_ = sut.Close()
+		})
+	})
+
+	Context("Partial flushers", func() {
+		It("retries and fails only the items a partial flusher reports", func() {
+			// arrange
+			errThrottled := errors.New("throttled")
+			var writes [][]int
+			failed := make(chan []int, 1)
+			acks := map[int]error{}
+			var mu sync.Mutex
+			sut := buffer.New[int]().
+				WithSize(3).
+				WithPartialFlusher(buffer.PartialFlusherFunc[int](func(items []int) ([]int, error) {
+					writes = append(writes, append([]int(nil), items...))
+					return []int{2}, errThrottled
+				})).
+				WithRetries(1, time.Millisecond).
+				WithErrorHandler(func(err error, items []int) { failed <- items })
+
+			// act
+			var err error
+			for i := 1; i <= 3; i++ {
+				err = errors.Join(err, sut.PushWithAck(i, func(err error) {
+					mu.Lock()
+					defer mu.Unlock()
+					acks[i] = err
+				}))
+			}
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(failed).Should(Receive(Equal([]int{2})))
+			Expect(sut.Close()).To(Succeed())
+			Expect(writes).To(Equal([][]int{{1, 2, 3}, {2}}))
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(acks).To(HaveLen(3))
+			Expect(acks[1]).To(Succeed())
+			Expect(acks[2]).To(MatchError(errThrottled))
+			Expect(acks[3]).To(Succeed())
+		})
+
+		It("requeues only the items a partial flusher reports", func() {
+			// arrange
+			batches := make(chan []int, 2)
+			sut := buffer.New[int]().
+				WithSize(3).
+				WithPartialFlusher(buffer.PartialFlusherFunc[int](func(items []int) ([]int, error) {
+					batches <- append([]int(nil), items...)
+					if items[0] == 1 {
+						return []int{3}, nil
+					}
+					return nil, nil
+				})).
+				WithRequeueOnError(2)
+
+			// act
+			var err error
+			for i := 1; i <= 5; i++ {
+				err = errors.Join(err, sut.Push(i))
+			}
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(batches).Should(Receive(Equal([]int{1, 2, 3})))
+			Eventually(batches).Should(Receive(Equal([]int{3, 4, 5})))
+			_ = sut.Close()
+		})
+	})
+
+	Context("Panic handling", func() {
+		It("hands a panic of the flusher to the panic handler", func() {
+			// arrange
+			type recovery struct {
+				recovered any
+				stack     []byte
+			}
+			recovered := make(chan recovery, 1)
+			failed := make(chan error, 1)
+			acks := make(chan error, 1)
+			batches := make(chan []int, 1)
+			sut := buffer.New[int]().
+				WithSize(1).
+				WithFlusher(buffer.FlusherFunc[int](func(items []int) error {
+					if items[0] == 1 {
+						panic("bug")
+					}
+					batches <- items
+					return nil
+				})).
+				WithErrorHandler(func(err error, items []int) { failed <- err }).
+				WithPanicHandler(func(value any, stack []byte) { recovered <- recovery{value, stack} })
+
+			// act
+			err := sut.PushWithAck(1, func(err error) { acks <- err })
+			err1 := sut.Push(2)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			var result recovery
+			Eventually(recovered).Should(Receive(&result))
+			Expect(result.recovered).To(Equal("bug"))
+			Expect(string(result.stack)).To(ContainSubstring("panic"))
+			Eventually(acks).Should(Receive(MatchError(buffer.ErrFlusherPanicked)))
+			Eventually(batches).Should(Receive(Equal([]int{2})))
+			Expect(sut.Close()).To(Succeed())
+			Expect(failed).NotTo(Receive())
+		})
+	})
+
+	Context("Ordering", func() {
+		It("flushes items in push order across mixed triggers", func() {
+			// arrange
+			var mu sync.Mutex
+			var flushed []int
+			sut := buffer.New[int]().
+				WithSize(3).
+				WithFlushInterval(5 * time.Millisecond).
+				WithFlusher(buffer.FlusherFunc[int](func(items []int) error {
+					mu.Lock()
+					defer mu.Unlock()
+					flushed = append(flushed, items...)
+					return nil
+				}))
+
+			// act
+			var pushed []int
+			for i := 0; i < 100; i++ {
+				Expect(sut.Push(i)).To(Succeed())
+				pushed = append(pushed, i)
+
+				switch {
+				case i%7 == 0:
+					Expect(sut.Flush()).To(Succeed())
+				case i%11 == 0:
+					time.Sleep(10 * time.Millisecond)
+				}
+			}
+			err := sut.Close()
+
+			// assert
+			Expect(err).To(Succeed())
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(flushed).To(Equal(pushed))
+		})
+	})
+
+	Context("Closing", func() {
+		It("flushes the buffer and closes it when Close is called", func(done Done) {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+			_ = sut.Push(2)
+
+			// act
+			err1 := sut.Close()
+
+			// assert
+			result := <-flusher.Done
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(result.Items).To(ConsistOf(1, 2))
+			close(done)
+		})
+
+		It("refuses pushes while the final flush is running", func() {
 			// arrange
-			flusher.Func = func() { select {} }
-			sut := buffer.New[any]().
-				WithSize(2).
-				WithFlusher(flusher).
-				WithPushTimeout(time.Second)
+			started := make(chan struct{})
+			release := make(chan struct{})
+			batches := NewMockFlusher[int]()
+			batches.Func = func() {
+				close(started)
+				<-release
+			}
+			sut := buffer.New[int]().
+				WithSize(5).
+				WithFlusher(batches).
+				WithChannelBuffer(5)
+
+			err := sut.Push(1)
+			closed := make(chan error, 1)
+			go func() { closed <- sut.Close() }()
+			<-started
 
 			// act
-			err1 := sut.Push(1)
-			err2 := sut.Push(2)
-			err3 := sut.Push(3)
+			acks := make(chan error, 1)
+			err1 := sut.Push(2)
+			err2 := sut.PushWithAck(3, func(err error) { acks <- err })
+			close(release)
 
 			// assert
-			Expect(err1).To(Succeed())
-			Expect(err2).To(Succeed())
-			Expect(err3).To(MatchError(buffer.ErrTimeout))
+			Expect(err).To(Succeed())
+			Expect(err1).To(MatchError(buffer.ErrClosed))
+			Expect(err2).To(MatchError(buffer.ErrClosed))
+			Eventually(closed).Should(Receive(BeNil()))
+			Expect((<-batches.Done).Items).To(Equal([]int{1}))
+			Expect(acks).NotTo(Receive())
 		})
 
-		It("fails when the buffer is closed", func() {
+		It("writes every item accepted into the channel buffer while closing", func() {
 			// arrange
-			sut := buffer.New[any]().
+			var accepted, written atomic.Int32
+			sut := buffer.New[int]().
 				WithSize(2).
-				WithFlusher(flusher)
+				WithFlusher(buffer.FlusherFunc[int](func([]int) error { return nil })).
+				WithChannelBuffer(4)
 
 			err := sut.Push(0)
 
-			_ = sut.Close()
-
 			// act
-			err1 := sut.Push(1)
+			var wg sync.WaitGroup
+			for range 8 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; ; i++ {
+						if sut.PushWithAck(i, func(err error) {
+							if err == nil {
+								written.Add(1)
+							}
+						}) != nil {
+							return
+						}
+						accepted.Add(1)
+					}
+				}()
+			}
+			time.Sleep(10 * time.Millisecond)
+			err1 := sut.Close()
+			wg.Wait()
 
 			// assert
 			Expect(err).To(Succeed())
-			Expect(err1).To(MatchError(buffer.ErrClosed))
+			Expect(err1).To(Succeed())
+			Expect(written.Load()).To(Equal(accepted.Load()))
 		})
-	})
 
-	Context("Flushing", func() {
-		It("flushes the buffer when it fills up", func(done Done) {
+		It("drops pushes while the final flush is running when configured to", func() {
 			// arrange
-			sut := buffer.New[any]().
+			started := make(chan struct{})
+			release := make(chan struct{})
+			batches := NewMockFlusher[int]()
+			batches.Func = func() {
+				close(started)
+				<-release
+			}
+			sut := buffer.New[int]().
 				WithSize(5).
-				WithFlusher(flusher)
+				WithFlusher(batches).
+				WithChannelBuffer(5).
+				WithClosedPushBehavior(buffer.SilentlyDrop)
 
-			// act
 			err := sut.Push(1)
-			_ = sut.Push(2)
-			_ = sut.Push(3)
-			_ = sut.Push(4)
-			_ = sut.Push(5)
+			go func() { _ = sut.Close() }()
+			<-started
 
-			Expect(err).To(Succeed())
+			// act
+			acks := make(chan error, 1)
+			err1 := sut.PushWithAck(2, func(err error) { acks <- err })
+			close(release)
 
 			// assert
-			result := <-flusher.Done
-			Expect(result.Items).To(ConsistOf(1, 2, 3, 4, 5))
-			close(done)
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(acks).To(Receive(MatchError(buffer.ErrClosed)))
 		})
 
-		It("flushes the buffer when the provided interval has elapsed", func(done Done) {
+		It("discards the remaining items when flushing on close is disabled", func() {
 			// arrange
-			interval := 3 * time.Second
-			start := time.Now()
 			sut := buffer.New[any]().
-				WithSize(5).
+				WithSize(3).
 				WithFlusher(flusher).
-				WithFlushInterval(interval)
+				WithFlushOnClose(false)
 
-			// act
 			err := sut.Push(1)
+			_ = sut.Push(2)
+
+			// act
+			err1 := sut.Close()
 
 			// assert
-			result := <-flusher.Done
 			Expect(err).To(Succeed())
-			Expect(result.Items).To(ConsistOf(1))
-			Expect(result.Time).To(BeTemporally("~", start, interval+time.Second))
-			close(done)
-		}, 5)
+			Expect(err1).To(Succeed())
+			Expect(flusher.Done).NotTo(Receive())
+			Expect(sut.Discarded()).To(Equal([]any{1, 2}))
+		})
 
-		It("flushes the buffer when Flush is called", func(done Done) {
+		It("calls the close hook once after the final flush", func() {
 			// arrange
+			var calls []string
 			sut := buffer.New[any]().
 				WithSize(3).
-				WithFlusher(flusher)
+				WithFlusher(buffer.FlusherFunc[any](func(items []any) error {
+					calls = append(calls, "flush")
+					return nil
+				})).
+				WithOnClose(func() { calls = append(calls, "close") })
 
 			err := sut.Push(1)
-			_ = sut.Push(2)
 
 			// act
-			err1 := sut.Flush()
+			err1 := sut.Close()
 
 			// assert
-			result := <-flusher.Done
 			Expect(err).To(Succeed())
 			Expect(err1).To(Succeed())
-			Expect(result.Items).To(ConsistOf(1, 2))
-			close(done)
+			Expect(calls).To(Equal([]string{"flush", "close"}))
 		})
 
-		It("fails when Flush cannot execute in a timely fashion", func() {
+		It("closes the flusher after the final flush", func() {
 			// arrange
-			flusher.Func = func() { time.Sleep(3 * time.Second) }
+			flusher := &ClosingFlusher[any]{}
 			sut := buffer.New[any]().
-				WithSize(1).
+				WithSize(3).
 				WithFlusher(flusher).
-				WithFlushTimeout(time.Second)
+				WithFlusherClose()
 
 			err := sut.Push(1)
 
 			// act
-			err1 := sut.Flush()
+			err1 := sut.Close()
 
 			// assert
 			Expect(err).To(Succeed())
-			Expect(err1).To(MatchError(buffer.ErrTimeout))
+			Expect(err1).To(Succeed())
+			Expect(flusher.Calls).To(Equal([]string{"write", "close"}))
 		})
 
-		It("fails when the buffer is closed", func() {
+		It("returns the error of closing the flusher", func() {
 			// arrange
+			closeErr := errors.New("close failed")
+			flusher := &ClosingFlusher[any]{Err: closeErr}
 			sut := buffer.New[any]().
-				WithSize(2).
-				WithFlusher(flusher)
+				WithSize(3).
+				WithFlusher(flusher).
+				WithFlusherClose()
 
 			err := sut.Push(1)
 
-			_ = sut.Close()
-
 			// act
-			err1 := sut.Flush()
+			err1 := sut.Close()
 
 			// assert
 			Expect(err).To(Succeed())
-			Expect(err1).To(MatchError(buffer.ErrClosed))
+			Expect(err1).To(MatchError(closeErr))
+			Expect(sut.Close()).To(MatchError(buffer.ErrClosed))
 		})
-	})
 
-	Context("Closing", func() {
-		It("flushes the buffer and closes it when Close is called", func(done Done) {
+		It("leaves the flusher open unless enabled", func() {
 			// arrange
+			flusher := &ClosingFlusher[any]{}
 			sut := buffer.New[any]().
 				WithSize(3).
 				WithFlusher(flusher)
 
 			err := sut.Push(1)
-			_ = sut.Push(2)
 
 			// act
 			err1 := sut.Close()
 
 			// assert
-			result := <-flusher.Done
 			Expect(err).To(Succeed())
 			Expect(err1).To(Succeed())
-			Expect(result.Items).To(ConsistOf(1, 2))
-			close(done)
+			Expect(flusher.Calls).To(Equal([]string{"write"}))
+		})
+
+		It("fails when the buffer is not initialized", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher)
+
+			// act
+			err := sut.Close()
+
+			// assert
+			Expect(err).To(MatchError(buffer.ErrNotInitialized))
 		})
 
 		It("fails when Close cannot execute in a timely fashion", func() {
@@ -302,6 +3073,28 @@ var _ = Describe("Buffer", func() {
 			Expect(err1).To(MatchError(buffer.ErrTimeout))
 		})
 
+		It("signals the consume goroutine even if Close times out mid-flush", func() {
+			// arrange
+			flusher.Func = func() { time.Sleep(500 * time.Millisecond) }
+
+			sut := buffer.New[any]().
+				WithSize(1).
+				WithFlusher(flusher).
+				WithCloseTimeout(100 * time.Millisecond)
+
+			err := sut.Push(1)
+
+			// act
+			err1 := sut.Close()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(MatchError(buffer.ErrTimeout))
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			Expect(sut.Wait(ctx)).To(Succeed())
+		})
+
 		It("fails when the buffer is closed", func() {
 			// arrange
 			flusher.Func = func() {}
@@ -342,6 +3135,180 @@ var _ = Describe("Buffer", func() {
 			Expect(err).To(Succeed())
 			Expect(err1).To(MatchError(buffer.ErrTimeout))
 			Expect(err2).To(Succeed())
+			Expect(sut.Lost()).To(BeZero())
+		})
+
+		It("returns the buffered items instead of flushing them when drained", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(5).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+			_ = sut.Push(2)
+
+			// act
+			items, err1 := sut.DrainToSlice()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(items).To(Equal([]any{1, 2}))
+			Expect(flusher.Done).NotTo(Receive())
+			Expect(sut.Push(3)).To(MatchError(buffer.ErrClosed))
+		})
+
+		It("fails to drain a buffer that is not initialized", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(5).
+				WithFlusher(flusher)
+
+			// act
+			items, err := sut.DrainToSlice()
+
+			// assert
+			Expect(items).To(BeNil())
+			Expect(err).To(MatchError(buffer.ErrNotInitialized))
+		})
+
+		It("reports the items lost when Close times out", func() {
+			// arrange
+			flusher.Func = func() { time.Sleep(500 * time.Millisecond) }
+
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithFlusher(flusher).
+				WithCloseTimeout(100 * time.Millisecond)
+
+			err := sut.Push(1)
+			_ = sut.Push(2)
+
+			// act
+			err1 := sut.Close()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(MatchError(buffer.ErrTimeout))
+			Expect(err1).To(MatchError(buffer.ErrDataLoss))
+			Expect(err1).To(MatchError(ContainSubstring("2 items")))
+			Expect(sut.Lost()).To(Equal(2))
+		})
+	})
+
+	Context("Waiting", func() {
+		It("returns immediately when the buffer was never initialized", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(1).
+				WithFlusher(flusher)
+
+			// act
+			err := sut.Wait(context.Background())
+
+			// assert
+			Expect(err).To(Succeed())
+		})
+
+		It("blocks until the buffer is closed", func(done Done) {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+			waited := make(chan error)
+			go func() { waited <- sut.Wait(context.Background()) }()
+
+			// act
+			Consistently(waited).ShouldNot(Receive())
+			err1 := sut.Close()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(<-waited).To(Succeed())
+			close(done)
+		})
+
+		It("fails when the context is done before the buffer is closed", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			// act
+			err1 := sut.Wait(ctx)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(MatchError(context.DeadlineExceeded))
+		})
+
+		It("blocks until the buffer is empty", func(done Done) {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+			waited := make(chan error)
+			go func() { waited <- sut.WaitEmpty(context.Background()) }()
+
+			// act
+			Consistently(waited).ShouldNot(Receive())
+			err1 := sut.Flush()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(<-waited).To(Succeed())
+			Expect(sut.WaitEmpty(context.Background())).To(Succeed())
+			_ = sut.Close()
+			close(done)
+		})
+
+		It("fails when the context is done before the buffer is empty", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher)
+
+			err := sut.Push(1)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			// act
+			err1 := sut.WaitEmpty(ctx)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(MatchError(context.DeadlineExceeded))
+			_ = sut.Close()
+		})
+
+		It("fails when the buffer is closed while items are still buffered", func() {
+			// arrange
+			sut := buffer.New[any]().
+				WithSize(2).
+				WithFlusher(flusher).
+				WithFlushOnClose(false)
+
+			err := sut.Push(1)
+			waited := make(chan error)
+			go func() { waited <- sut.WaitEmpty(context.Background()) }()
+
+			// act
+			Consistently(waited).ShouldNot(Receive())
+			_ = sut.Close()
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(waited).Should(Receive(MatchError(buffer.ErrClosed)))
 		})
 	})
 })
@@ -350,6 +3317,7 @@ type (
 	MockFlusher[T any] struct {
 		Done chan *WriteCall[T]
 		Func func()
+		Err  error
 	}
 
 	WriteCall[T any] struct {
@@ -358,7 +3326,7 @@ type (
 	}
 )
 
-func (flusher *MockFlusher[T]) Write(items []T) {
+func (flusher *MockFlusher[T]) Write(items []T) error {
 	call := &WriteCall[T]{
 		Time:  time.Now(),
 		Items: items,
@@ -369,6 +3337,23 @@ func (flusher *MockFlusher[T]) Write(items []T) {
 	}
 
 	flusher.Done <- call
+	return flusher.Err
+}
+
+// ClosingFlusher is a flusher that implements io.Closer.
+type ClosingFlusher[T any] struct {
+	Calls []string
+	Err   error
+}
+
+func (flusher *ClosingFlusher[T]) Write(items []T) error {
+	flusher.Calls = append(flusher.Calls, "write")
+	return nil
+}
+
+func (flusher *ClosingFlusher[T]) Close() error {
+	flusher.Calls = append(flusher.Calls, "close")
+	return flusher.Err
 }
 
 func NewMockFlusher[T any]() *MockFlusher[T] {
@@ -0,0 +1,106 @@
+// Package bufferhttp provides a flusher that posts batches to an HTTP endpoint.
+package bufferhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/omniboost/go-buffer"
+)
+
+var _ buffer.Flusher[any] = (*HTTPFlusher[any])(nil)
+
+// ErrUnexpectedStatus indicates the endpoint answered with a non-2xx status.
+var ErrUnexpectedStatus = errors.New("unexpected response status")
+
+type (
+	// HTTPFlusher represents a flusher that posts every batch as a JSON array
+	// to a URL.
+	HTTPFlusher[T any] struct {
+		url     string
+		client  *http.Client
+		header  http.Header
+		timeout time.Duration
+	}
+)
+
+// New creates a flusher that posts batches to url with the default HTTP client.
+func New[T any](url string) *HTTPFlusher[T] {
+	return &HTTPFlusher[T]{
+		url:    url,
+		client: http.DefaultClient,
+		header: http.Header{},
+	}
+}
+
+// WithClient sets the HTTP client the batches are posted with.
+func (f *HTTPFlusher[T]) WithClient(client *http.Client) *HTTPFlusher[T] {
+	f.client = client
+	return f
+}
+
+// WithHeader adds a header to every request, for instance to authenticate.
+func (f *HTTPFlusher[T]) WithHeader(key, value string) *HTTPFlusher[T] {
+	f.header.Add(key, value)
+	return f
+}
+
+// WithTimeout sets how long a single request may take, on top of any deadline
+// of the client. Zero means no timeout.
+func (f *HTTPFlusher[T]) WithTimeout(timeout time.Duration) *HTTPFlusher[T] {
+	f.timeout = timeout
+	return f
+}
+
+// Write posts items, see WriteContext.
+func (f *HTTPFlusher[T]) Write(items []T) error {
+	return f.WriteContext(context.Background(), items)
+}
+
+// WriteContext posts items as a JSON array, with ctx bounding the request. It
+// can be used as a context-aware flusher with buffer.ContextFlusherFunc. An
+// empty batch is not posted at all. A response with a non-2xx status fails with
+// an ErrUnexpectedStatus, so that retries and dead-lettering engage.
+func (f *HTTPFlusher[T]) WriteContext(ctx context.Context, items []T) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+
+	if f.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = f.header.Clone()
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// drain the body so that the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%w: %s", ErrUnexpectedStatus, resp.Status)
+	}
+
+	return nil
+}
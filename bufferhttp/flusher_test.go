@@ -0,0 +1,122 @@
+package bufferhttp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/omniboost/go-buffer"
+	"github.com/omniboost/go-buffer/bufferhttp"
+)
+
+var _ = Describe("HTTPFlusher", func() {
+	type request struct {
+		items  []int
+		header http.Header
+	}
+
+	var (
+		requests chan request
+		status   int
+		server   *httptest.Server
+	)
+
+	BeforeEach(func() {
+		requests = make(chan request, 1)
+		status = http.StatusNoContent
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var items []int
+			_ = json.NewDecoder(r.Body).Decode(&items)
+			requests <- request{items: items, header: r.Header}
+			w.WriteHeader(status)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("posts every batch as a JSON array", func() {
+		// arrange
+		sut := bufferhttp.New[int](server.URL).
+			WithHeader("Authorization", "Bearer token")
+
+		// act
+		err := sut.Write([]int{1, 2, 3})
+
+		// assert
+		Expect(err).To(Succeed())
+		var received request
+		Expect(requests).To(Receive(&received))
+		Expect(received.items).To(Equal([]int{1, 2, 3}))
+		Expect(received.header.Get("Content-Type")).To(Equal("application/json"))
+		Expect(received.header.Get("Authorization")).To(Equal("Bearer token"))
+	})
+
+	It("fails on a non-2xx response", func() {
+		// arrange
+		status = http.StatusServiceUnavailable
+		sut := bufferhttp.New[int](server.URL)
+
+		// act
+		err := sut.Write([]int{1})
+
+		// assert
+		Expect(err).To(MatchError(bufferhttp.ErrUnexpectedStatus))
+		Expect(err).To(MatchError(ContainSubstring("503")))
+	})
+
+	It("does not post an empty batch", func() {
+		// arrange
+		sut := bufferhttp.New[int](server.URL)
+
+		// act
+		err := sut.Write(nil)
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(requests).NotTo(Receive())
+	})
+
+	It("gives up on a request that exceeds the timeout", func() {
+		// arrange
+		release := make(chan struct{})
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer slow.Close()
+		defer close(release)
+		sut := bufferhttp.New[int](slow.URL).
+			WithClient(slow.Client()).
+			WithTimeout(50 * time.Millisecond)
+
+		// act
+		err := sut.Write([]int{1})
+
+		// assert
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+	})
+
+	It("flushes a buffer to the endpoint", func() {
+		// arrange
+		sut := buffer.New[int]().
+			WithSize(2).
+			WithFlusher(bufferhttp.New[int](server.URL))
+
+		// act
+		err := sut.Push(1)
+		_ = sut.Push(2)
+
+		// assert
+		Expect(err).To(Succeed())
+		var received request
+		Eventually(requests).Should(Receive(&received))
+		Expect(received.items).To(Equal([]int{1, 2}))
+		_ = sut.Close()
+	})
+})
@@ -0,0 +1,13 @@
+package bufferhttp_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestBufferHTTP(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "go-buffer http suite")
+}
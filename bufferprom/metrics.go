@@ -0,0 +1,87 @@
+// Package bufferprom provides a Prometheus implementation of buffer.Metrics.
+package bufferprom
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/omniboost/go-buffer"
+)
+
+var _ buffer.Metrics = (*PrometheusMetrics)(nil)
+
+type (
+	// PrometheusMetrics represents buffer metrics exported as Prometheus collectors.
+	PrometheusMetrics struct {
+		Pushed        prometheus.Counter
+		Batches       prometheus.Counter
+		Items         prometheus.Counter
+		Dropped       prometheus.Counter
+		Errors        prometheus.Counter
+		FlushDuration prometheus.Histogram
+	}
+)
+
+// New creates the buffer metrics and registers them with the provided
+// registerer. Every metric name is prefixed with namespace and subsystem.
+func New(registerer prometheus.Registerer, namespace, subsystem string) (*PrometheusMetrics, error) {
+	counter := func(name, help string) prometheus.Counter {
+		return prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      name,
+			Help:      help,
+		})
+	}
+
+	metrics := &PrometheusMetrics{
+		Pushed:  counter("pushed_items_total", "Number of items accepted by the buffer."),
+		Batches: counter("flushed_batches_total", "Number of batches handed to the flusher."),
+		Items:   counter("flushed_items_total", "Number of items handed to the flusher."),
+		Dropped: counter("dropped_items_total", "Number of items that could not be pushed."),
+		Errors:  counter("flush_errors_total", "Number of batches that could not be written."),
+		FlushDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "flush_duration_seconds",
+			Help:      "Time it took to write a batch.",
+		}),
+	}
+
+	for _, collector := range []prometheus.Collector{
+		metrics.Pushed,
+		metrics.Batches,
+		metrics.Items,
+		metrics.Dropped,
+		metrics.Errors,
+		metrics.FlushDuration,
+	} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+
+	return metrics, nil
+}
+
+func (metrics *PrometheusMetrics) IncPushed(n int) {
+	metrics.Pushed.Add(float64(n))
+}
+
+func (metrics *PrometheusMetrics) IncFlushed(batches, items int) {
+	metrics.Batches.Add(float64(batches))
+	metrics.Items.Add(float64(items))
+}
+
+func (metrics *PrometheusMetrics) IncDropped(n int) {
+	metrics.Dropped.Add(float64(n))
+}
+
+func (metrics *PrometheusMetrics) ObserveFlushDuration(d time.Duration) {
+	metrics.FlushDuration.Observe(d.Seconds())
+}
+
+func (metrics *PrometheusMetrics) IncErrors(n int) {
+	metrics.Errors.Add(float64(n))
+}
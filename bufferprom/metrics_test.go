@@ -0,0 +1,51 @@
+package bufferprom_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/omniboost/go-buffer"
+	"github.com/omniboost/go-buffer/bufferprom"
+)
+
+var _ = Describe("PrometheusMetrics", func() {
+	It("records the buffer's activity", func() {
+		// arrange
+		registry := prometheus.NewRegistry()
+		metrics, err := bufferprom.New(registry, "test", "buffer")
+		Expect(err).To(Succeed())
+
+		sut := buffer.New[int]().
+			WithSize(2).
+			WithFlusher(buffer.FlusherFunc[int](func([]int) error { return nil })).
+			WithMetrics(metrics)
+
+		// act
+		err1 := sut.Push(1)
+		_ = sut.Push(2)
+		err2 := sut.Close()
+
+		// assert
+		Expect(err1).To(Succeed())
+		Expect(err2).To(Succeed())
+		Expect(testutil.ToFloat64(metrics.Pushed)).To(Equal(2.0))
+		Expect(testutil.ToFloat64(metrics.Batches)).To(Equal(1.0))
+		Expect(testutil.ToFloat64(metrics.Items)).To(Equal(2.0))
+		Expect(testutil.CollectAndCount(metrics.FlushDuration)).To(Equal(1))
+	})
+
+	It("fails when the metrics are already registered", func() {
+		// arrange
+		registry := prometheus.NewRegistry()
+		_, err := bufferprom.New(registry, "test", "buffer")
+		Expect(err).To(Succeed())
+
+		// act
+		_, err1 := bufferprom.New(registry, "test", "buffer")
+
+		// assert
+		Expect(err1).To(HaveOccurred())
+	})
+})
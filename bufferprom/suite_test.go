@@ -0,0 +1,13 @@
+package bufferprom_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestBufferProm(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "go-buffer prometheus suite")
+}
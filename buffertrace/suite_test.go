@@ -0,0 +1,13 @@
+package buffertrace_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestBufferTrace(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "go-buffer tracing suite")
+}
@@ -0,0 +1,104 @@
+// Package buffertrace provides OpenTelemetry tracing of buffer flushes.
+package buffertrace
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/omniboost/go-buffer"
+)
+
+// SpanName is the name of the span started around every write of a batch.
+const SpanName = "buffer.flush"
+
+// maxLinks caps the number of push spans a single flush span is linked to.
+const maxLinks = 128
+
+const (
+	// AttributeBatchSize is the number of items in the written batch.
+	AttributeBatchSize = attribute.Key("buffer.batch.size")
+	// AttributeFlushReason is what triggered the flush, see buffer.FlushReason.
+	AttributeFlushReason = attribute.Key("buffer.flush.reason")
+)
+
+type (
+	// Buffer represents a buffer whose flushes are traced.
+	Buffer[T any] struct {
+		*buffer.Buffer[T]
+		tracer  trace.Tracer
+		flusher buffer.ContextFlusher[T]
+	}
+)
+
+// Wrap makes b start a span around every write of a batch, using tracer. It
+// must be called once b's flusher is set, and before anything is pushed.
+//
+// Spans are named SpanName and carry the size of the batch and the reason of
+// the flush. They are children of the context passed to FlushContext, if any,
+// and are linked to the spans of the pushes of the batch made through
+// PushContext or PushWithAckContext. When the batch is written in chunks or
+// groups, every write is linked to the pushes of the whole batch.
+//
+// Only the writes of b's Flusher or ContextFlusher are traced. A timestamped
+// flusher, a partial flusher or a flusher selector takes precedence over them
+// and is handed no context, so the batches it writes get no span.
+func Wrap[T any](b *buffer.Buffer[T], tracer trace.Tracer) *Buffer[T] {
+	traced := &Buffer[T]{
+		Buffer:  b,
+		tracer:  tracer,
+		flusher: b.ContextFlusher,
+	}
+	if traced.flusher == nil {
+		flusher := b.Flusher
+		traced.flusher = buffer.ContextFlusherFunc[T](func(ctx context.Context, items []T) error {
+			return flusher.Write(items)
+		})
+	}
+
+	b.ContextFlusher = buffer.ContextFlusherFunc[T](traced.write)
+	return traced
+}
+
+// PushContext pushes an item like Push, linking the span in ctx, if any, to
+// the span of the flush the item is written by. The context travels with the
+// item, as with PushWithAckContext, so WithSkipCancelled applies to it.
+func (b *Buffer[T]) PushContext(ctx context.Context, item T) error {
+	return b.PushWithAckContext(ctx, item, nil)
+}
+
+func (b *Buffer[T]) write(ctx context.Context, items []T) error {
+	var links []trace.Link
+	for _, pushed := range buffer.ItemContextsFromContext(ctx) {
+		if len(links) == maxLinks {
+			break
+		}
+		if pushed == nil {
+			continue
+		}
+		if spanContext := trace.SpanContextFromContext(pushed); spanContext.IsValid() {
+			links = append(links, trace.Link{SpanContext: spanContext})
+		}
+	}
+
+	reason, _ := buffer.FlushReasonFromContext(ctx)
+	ctx, span := b.tracer.Start(ctx, SpanName,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithLinks(links...),
+		trace.WithAttributes(
+			AttributeBatchSize.Int(len(items)),
+			AttributeFlushReason.String(reason.String()),
+		),
+	)
+	defer span.End()
+
+	err := b.flusher.Write(ctx, items)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
+}
@@ -0,0 +1,155 @@
+package buffertrace_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/omniboost/go-buffer"
+	"github.com/omniboost/go-buffer/buffertrace"
+)
+
+var _ = Describe("Buffer", func() {
+	var (
+		recorder *tracetest.SpanRecorder
+		provider *sdktrace.TracerProvider
+	)
+
+	BeforeEach(func() {
+		recorder = tracetest.NewSpanRecorder()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	})
+
+	It("starts a span around every write linked to the pushes", func() {
+		// arrange
+		tracer := provider.Tracer("test")
+		sut := buffertrace.Wrap(buffer.New[int]().
+			WithSize(2).
+			WithFlusher(buffer.FlusherFunc[int](func([]int) error { return nil })), tracer)
+
+		ctx, push := tracer.Start(context.Background(), "push")
+
+		// act
+		err1 := sut.PushContext(ctx, 1)
+		err2 := sut.PushContext(ctx, 2)
+		push.End()
+		err3 := sut.Close()
+
+		// assert
+		Expect(err1).To(Succeed())
+		Expect(err2).To(Succeed())
+		Expect(err3).To(Succeed())
+
+		var flushes []sdktrace.ReadOnlySpan
+		for _, span := range recorder.Ended() {
+			if span.Name() == buffertrace.SpanName {
+				flushes = append(flushes, span)
+			}
+		}
+		Expect(flushes).To(HaveLen(1))
+		Expect(flushes[0].Attributes()).To(ConsistOf(
+			buffertrace.AttributeBatchSize.Int(2),
+			buffertrace.AttributeFlushReason.String("full"),
+		))
+		Expect(flushes[0].Links()).To(HaveLen(2))
+		Expect(flushes[0].Links()[0].SpanContext.SpanID()).To(Equal(push.SpanContext().SpanID()))
+	})
+
+	It("links a push to the flush it triggers", func() {
+		// arrange
+		tracer := provider.Tracer("test")
+		sut := buffertrace.Wrap(buffer.New[int]().
+			WithSize(1).
+			WithFlusher(buffer.FlusherFunc[int](func([]int) error { return nil })), tracer)
+
+		var pushes []trace.Span
+		for range 3 {
+			_, push := tracer.Start(context.Background(), "push")
+			pushes = append(pushes, push)
+		}
+
+		// act
+		var err error
+		for i, push := range pushes {
+			err = errors.Join(err, sut.PushContext(trace.ContextWithSpan(context.Background(), push), i))
+			push.End()
+		}
+		err1 := sut.Close()
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(err1).To(Succeed())
+		var links []trace.SpanID
+		for _, span := range recorder.Ended() {
+			if span.Name() == buffertrace.SpanName {
+				Expect(span.Links()).To(HaveLen(1))
+				links = append(links, span.Links()[0].SpanContext.SpanID())
+			}
+		}
+		Expect(links).To(Equal([]trace.SpanID{
+			pushes[0].SpanContext().SpanID(),
+			pushes[1].SpanContext().SpanID(),
+			pushes[2].SpanContext().SpanID(),
+		}))
+	})
+
+	It("does not link a push that failed", func() {
+		// arrange
+		tracer := provider.Tracer("test")
+		sut := buffertrace.Wrap(buffer.New[int]().
+			WithSize(2).
+			WithFlusher(buffer.FlusherFunc[int](func([]int) error { return nil })).
+			WithItemValidator(func(item int) error {
+				if item < 0 {
+					return errors.New("negative")
+				}
+				return nil
+			}), tracer)
+
+		ctx, push := tracer.Start(context.Background(), "push")
+
+		// act
+		err1 := sut.PushContext(ctx, -1)
+		push.End()
+		err2 := sut.Push(1)
+		err3 := sut.Close()
+
+		// assert
+		Expect(err1).To(MatchError(buffer.ErrInvalidItem))
+		Expect(err2).To(Succeed())
+		Expect(err3).To(Succeed())
+		for _, span := range recorder.Ended() {
+			if span.Name() == buffertrace.SpanName {
+				Expect(span.Links()).To(BeEmpty())
+			}
+		}
+	})
+
+	It("wraps a context-aware flusher and records its errors", func() {
+		// arrange
+		errSink := errors.New("sink failed")
+		sut := buffertrace.Wrap(buffer.New[int]().
+			WithSize(1).
+			WithContextFlusher(buffer.ContextFlusherFunc[int](func(context.Context, []int) error { return errSink })), provider.Tracer("test"))
+
+		// act
+		err1 := sut.PushContext(context.Background(), 1)
+		err2 := sut.Close()
+
+		// assert
+		Expect(err1).To(Succeed())
+		Expect(err2).To(Succeed())
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Status().Code).To(Equal(codes.Error))
+		Expect(spans[0].Links()).To(BeEmpty())
+		Expect(spans[0].Attributes()).To(ContainElement(attribute.Int("buffer.batch.size", 1)))
+	})
+})
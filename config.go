@@ -0,0 +1,110 @@
+package buffer
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+type (
+	// Duration represents a duration of a Config. It is decoded from a string
+	// accepted by time.ParseDuration, such as "1.5s", or from a number of
+	// nanoseconds, and encoded as a string.
+	Duration time.Duration
+
+	// Config represents the tunable settings of a buffer, as loaded from a
+	// configuration file. Zero fields keep the defaults of New.
+	Config struct {
+		Size                uint     `json:"size" yaml:"size"`
+		FlushInterval       Duration `json:"flushInterval" yaml:"flushInterval"`
+		PushTimeout         Duration `json:"pushTimeout" yaml:"pushTimeout"`
+		FlushTimeout        Duration `json:"flushTimeout" yaml:"flushTimeout"`
+		PerItemFlushTimeout Duration `json:"perItemFlushTimeout" yaml:"perItemFlushTimeout"`
+		CloseTimeout        Duration `json:"closeTimeout" yaml:"closeTimeout"`
+		Retries             uint     `json:"retries" yaml:"retries"`
+		RetryBackoff        Duration `json:"retryBackoff" yaml:"retryBackoff"`
+		RetryJitter         float64  `json:"retryJitter" yaml:"retryJitter"`
+		RetryMaxElapsed     Duration `json:"retryMaxElapsed" yaml:"retryMaxElapsed"`
+		HighWaterMark       uint     `json:"highWaterMark" yaml:"highWaterMark"`
+		LowWaterMark        uint     `json:"lowWaterMark" yaml:"lowWaterMark"`
+		InitialDelay        Duration `json:"initialDelay" yaml:"initialDelay"`
+		RequeueAttempts     int      `json:"requeueAttempts" yaml:"requeueAttempts"`
+		SampleRate          float64  `json:"sampleRate" yaml:"sampleRate"`
+		MaxWriteBatch       int      `json:"maxWriteBatch" yaml:"maxWriteBatch"`
+		MaxInFlightBatches  int      `json:"maxInFlightBatches" yaml:"maxInFlightBatches"`
+		ChannelBuffer       int      `json:"channelBuffer" yaml:"channelBuffer"`
+		PushRetryAttempts   int      `json:"pushRetryAttempts" yaml:"pushRetryAttempts"`
+		PushRetryBackoff    Duration `json:"pushRetryBackoff" yaml:"pushRetryBackoff"`
+	}
+)
+
+// NewFromConfig creates a buffer with the settings of cfg that writes to
+// flusher. The buffer can be further configured with the fluent API.
+//
+// It returns the same validation errors as Validate, and never returns a buffer
+// that does not pass validation.
+func NewFromConfig[T any](cfg Config, flusher Flusher[T]) (*Buffer[T], error) {
+	b := New[T]().
+		WithSize(cfg.Size).
+		WithFlusher(flusher).
+		WithFlushInterval(time.Duration(cfg.FlushInterval)).
+		WithPerItemFlushTimeout(time.Duration(cfg.PerItemFlushTimeout)).
+		WithRetries(cfg.Retries, time.Duration(cfg.RetryBackoff)).
+		WithRetryJitter(cfg.RetryJitter).
+		WithRetryMaxElapsed(time.Duration(cfg.RetryMaxElapsed)).
+		WithWaterMarks(cfg.HighWaterMark, cfg.LowWaterMark).
+		WithInitialDelay(time.Duration(cfg.InitialDelay)).
+		WithRequeueOnError(cfg.RequeueAttempts).
+		WithSampleRate(cfg.SampleRate).
+		WithMaxWriteBatch(cfg.MaxWriteBatch).
+		WithMaxInFlightBatches(cfg.MaxInFlightBatches).
+		WithChannelBuffer(cfg.ChannelBuffer).
+		WithPushRetry(cfg.PushRetryAttempts, time.Duration(cfg.PushRetryBackoff))
+
+	if cfg.PushTimeout != 0 {
+		b.WithPushTimeout(time.Duration(cfg.PushTimeout))
+	}
+	if cfg.FlushTimeout != 0 {
+		b.WithFlushTimeout(time.Duration(cfg.FlushTimeout))
+	}
+	if cfg.CloseTimeout != 0 {
+		b.WithCloseTimeout(time.Duration(cfg.CloseTimeout))
+	}
+
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// MarshalText encodes d as a string like "1.5s".
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText decodes a string accepted by time.ParseDuration into d.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+// UnmarshalJSON decodes a JSON string accepted by time.ParseDuration, or a JSON
+// number of nanoseconds, into d.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+
+		return d.UnmarshalText([]byte(text))
+	}
+
+	return json.Unmarshal(data, (*int64)(d))
+}
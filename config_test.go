@@ -0,0 +1,102 @@
+package buffer_test
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/omniboost/go-buffer"
+)
+
+var _ = Describe("Config", func() {
+	flusher := buffer.FlusherFunc[int](func([]int) error { return nil })
+
+	It("creates a buffer with the settings of the config", func() {
+		// arrange
+		var cfg buffer.Config
+		err := json.Unmarshal([]byte(`{"size": 5000, "flushInterval": 1000000000, "maxWriteBatch": 500}`), &cfg)
+
+		// act
+		sut, err1 := buffer.NewFromConfig[int](cfg, flusher)
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(err1).To(Succeed())
+		Expect(sut.Size).To(BeIdenticalTo(uint(5000)))
+		Expect(sut.FlushInterval).To(Equal(time.Second))
+		Expect(sut.MaxWriteBatch).To(Equal(500))
+		Expect(sut.Flusher).NotTo(BeNil())
+	})
+
+	It("decodes durations from strings", func() {
+		// arrange
+		var cfg buffer.Config
+		err := json.Unmarshal([]byte(`{"size": 10, "flushInterval": "1.5s", "closeTimeout": "2m"}`), &cfg)
+
+		// act
+		sut, err1 := buffer.NewFromConfig[int](cfg, flusher)
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(err1).To(Succeed())
+		Expect(sut.FlushInterval).To(Equal(1500 * time.Millisecond))
+		Expect(sut.CloseTimeout).To(Equal(2 * time.Minute))
+	})
+
+	It("encodes durations as strings", func() {
+		// act
+		data, err := json.Marshal(buffer.Config{FlushInterval: buffer.Duration(time.Second)})
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(string(data)).To(ContainSubstring(`"flushInterval":"1s"`))
+	})
+
+	It("fails to decode an invalid duration", func() {
+		// arrange
+		var cfg buffer.Config
+
+		// act
+		err := json.Unmarshal([]byte(`{"flushInterval": "soon"}`), &cfg)
+
+		// assert
+		Expect(err).To(MatchError(ContainSubstring("invalid duration")))
+	})
+
+	It("keeps the default timeouts for zero fields", func() {
+		// arrange
+		cfg := buffer.Config{Size: 10, CloseTimeout: buffer.Duration(5 * time.Second)}
+
+		// act
+		sut, err := buffer.NewFromConfig[int](cfg, flusher)
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(sut.PushTimeout).To(Equal(time.Second))
+		Expect(sut.FlushTimeout).To(Equal(time.Second))
+		Expect(sut.CloseTimeout).To(Equal(5 * time.Second))
+	})
+
+	It("returns the validation errors of the builder", func() {
+		// arrange
+		cfg := buffer.Config{Size: 10, FlushInterval: buffer.Duration(-time.Second)}
+
+		// act
+		sut, err := buffer.NewFromConfig[int](cfg, flusher)
+
+		// assert
+		Expect(sut).To(BeNil())
+		Expect(err).To(MatchError(buffer.ErrInvalidInterval))
+		Expect(err).To(MatchError(ContainSubstring("FlushInterval")))
+	})
+
+	It("fails without a size", func() {
+		// act
+		_, err := buffer.NewFromConfig[int](buffer.Config{}, flusher)
+
+		// assert
+		Expect(err).To(MatchError(buffer.ErrInvalidSize))
+	})
+})
@@ -0,0 +1,924 @@
+package buffer
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"slices"
+	"sort"
+	"time"
+)
+
+// consumer holds the state owned by the consume goroutine.
+type consumer[T any] struct {
+	buffer *Buffer[T]
+
+	items    []T
+	stamps   []time.Time
+	sizes    []int
+	attempts []int
+	acks     []func(err error)
+	ctxs     []context.Context
+	bytes    int
+	count    int
+	pushed   bool
+	closing  bool
+	stats    Stats
+
+	inFlight int
+	lanes    map[any]chan struct{}
+	rand     *rand.Rand
+	dedup    *dedupWindow
+
+	// keys indexes the buffered items by key when coalescing, it is reset
+	// whenever items move and rebuilt on demand.
+	keys map[any]int
+	// distinct holds the keys of the buffered items for the distinct key
+	// trigger, it is reset and rebuilt like keys.
+	distinct map[any]struct{}
+
+	ticker     <-chan time.Time
+	stopTicker func()
+	trigger    <-chan struct{}
+
+	// warmup fires once the initial delay has elapsed, flushing is suppressed
+	// until then and flush requests are deferred.
+	warmup   <-chan time.Time
+	warming  bool
+	deferred []flushRequest[T]
+
+	// debounce fires once Flush has not been called for the debounce period.
+	debounce      <-chan time.Time
+	debounceTimer *time.Timer
+
+	high, low uint
+
+	// limit is the number of items that fills the buffer, see
+	// WithSizeSchedule.
+	limit int
+}
+
+func (buffer *Buffer[T]) consume() {
+	c := buffer.newConsumer()
+	if buffer.InitialDelay > 0 {
+		timer := time.NewTimer(buffer.InitialDelay)
+		defer timer.Stop()
+		c.warmup, c.warming = timer.C, true
+	}
+	if buffer.Watchdog > 0 {
+		buffer.watchdog.start(buffer.Watchdog, buffer.Len, buffer.OnStuck)
+	}
+
+	c.run()
+}
+
+func (buffer *Buffer[T]) newConsumer() *consumer[T] {
+	c := &consumer[T]{
+		buffer:   buffer,
+		items:    make([]T, buffer.Size),
+		stamps:   make([]time.Time, buffer.Size),
+		sizes:    make([]int, buffer.Size),
+		attempts: make([]int, buffer.Size),
+		acks:     make([]func(err error), buffer.Size),
+		ctxs:     make([]context.Context, buffer.Size),
+		lanes:    map[any]chan struct{}{},
+	}
+	// the interval starts with the first push
+	c.stopTicker = func() {}
+	c.trigger = buffer.FlushTrigger
+	c.high, c.low = buffer.waterMarks()
+	c.limit = buffer.threshold()
+	if buffer.DedupKey != nil {
+		c.dedup = newDedupWindow(buffer.DedupWindow)
+	}
+	if buffer.SampleRate > 0 {
+		src := buffer.RandSource
+		if src == nil {
+			src = rand.NewSource(time.Now().UnixNano())
+		}
+		c.rand = rand.New(src)
+	}
+
+	return c
+}
+
+func (c *consumer[T]) run() {
+	buffer := c.buffer
+
+	isOpen := true
+	for isOpen {
+		dataCh, priorityCh := buffer.dataCh, buffer.priorityCh
+		if (c.warming || buffer.NoFlushOnFull) && c.full() {
+			// hold off pushes until the buffer gets flushed
+			dataCh, priorityCh = nil, nil
+		}
+
+		select {
+		case e := <-dataCh:
+			c.push(e)
+		case e := <-priorityCh:
+			c.add(e)
+			if !c.warming {
+				c.flush(c.count, flushRequest[T]{reason: FlushReasonPriority})
+			}
+		case <-c.ticker:
+			c.limit = buffer.threshold()
+			if c.count == 0 {
+				if c.pausable() {
+					c.pauseTicker()
+				}
+				c.skipped(FlushReasonInterval)
+				continue
+			}
+			if !c.warming {
+				c.flush(c.count, flushRequest[T]{reason: FlushReasonInterval})
+			}
+		case request := <-buffer.flushCh:
+			if c.warming && !request.drain && !request.snapshot {
+				c.deferred = append(c.deferred, request)
+				continue
+			}
+			c.handle(request)
+		case _, ok := <-c.trigger:
+			if !ok {
+				// a closed trigger fires once
+				c.trigger = nil
+			}
+			request := flushRequest[T]{reason: FlushReasonTrigger}
+			if c.warming {
+				c.deferred = append(c.deferred, request)
+				continue
+			}
+			c.flush(c.count, request)
+		case <-c.debounce:
+			c.debounce = nil
+			c.flush(c.count, flushRequest[T]{reason: FlushReasonManual})
+		case <-c.warmup:
+			c.warmUp()
+			c.flush(c.count, flushRequest[T]{reason: FlushReasonWarmUp})
+		case <-buffer.closeCh:
+			isOpen = false
+			c.shutdown()
+		case request := <-buffer.statsCh:
+			c.snapshot(request)
+		case result := <-buffer.resultCh:
+			c.complete(result)
+			c.resumeTicker()
+		}
+	}
+
+	c.close()
+}
+
+// shutdown performs the final flush once the buffer is closing.
+func (c *consumer[T]) shutdown() {
+	c.closing = true
+	// wait for the pushes that are sending an item, later ones are refused
+	c.buffer.sendMu.Lock()
+	c.buffer.sendMu.Unlock()
+	c.buffer.subscribers.emit(Event{Type: EventClosing})
+	if c.warming {
+		c.warmUp()
+	}
+	c.accept()
+	if !c.buffer.DiscardOnClose {
+		c.flush(c.count, flushRequest[T]{reason: FlushReasonClose})
+	}
+}
+
+// snapshot serves a request for the buffer's counters.
+func (c *consumer[T]) snapshot(request statsRequest) {
+	c.stats.Pending = c.count
+	c.stats.InFlight = c.inFlight
+	request.reply <- c.stats
+	if request.reset {
+		c.stats = Stats{}
+	}
+}
+
+// push adds an item that was pushed with Push, flushing the buffer once it is
+// full.
+func (c *consumer[T]) push(e entry[T]) {
+	flushes := c.stats.Flushes
+	c.reschedule()
+	c.add(e)
+	full := !c.warming && !c.buffer.NoFlushOnFull && c.full()
+	e.report(full || c.stats.Flushes > flushes)
+	if full {
+		c.flush(c.count, flushRequest[T]{reason: FlushReasonFull})
+	}
+}
+
+// accept adds the items still queued up in the channel buffer, so that the
+// final flush includes them, see WithChannelBuffer.
+func (c *consumer[T]) accept() {
+	for {
+		select {
+		case e := <-c.buffer.dataCh:
+			c.push(e)
+		default:
+			return
+		}
+	}
+}
+
+// handle serves a flush request, postponing a plain Flush while debouncing.
+func (c *consumer[T]) handle(request flushRequest[T]) {
+	c.buffer.taken(request.pending)
+	if request.drain {
+		c.drain(request.reply)
+		return
+	}
+	if request.snapshot {
+		request.reply <- flushReply[T]{items: append([]T{}, c.items[:c.count]...)}
+		return
+	}
+	if request.barrier != nil {
+		c.barrier(request)
+		return
+	}
+	if c.buffer.FlushDebounce > 0 && !c.buffer.Synchronous && request.reply == nil && !request.partial && request.ctx == nil {
+		if c.debounceTimer == nil {
+			c.debounceTimer = time.NewTimer(c.buffer.FlushDebounce)
+		} else {
+			c.debounceTimer.Reset(c.buffer.FlushDebounce)
+		}
+		c.debounce = c.debounceTimer.C
+		return
+	}
+
+	limit := c.count
+	if request.partial {
+		cutoff := time.Now().Add(-request.olderThan)
+		limit = sort.Search(c.count, func(i int) bool { return c.stamps[i].After(cutoff) })
+	}
+	c.flush(limit, request)
+}
+
+// barrier flushes the buffer and waits for every flush in flight before running
+// the barrier function, see Barrier.
+func (c *consumer[T]) barrier(request flushRequest[T]) {
+	reply, fn := request.reply, request.barrier
+	flushed := make(chan flushReply[T], 1)
+	request.reply, request.barrier = flushed, nil
+
+	c.flush(c.count, request)
+	for c.inFlight > 0 {
+		c.await()
+	}
+	c.resumeTicker()
+
+	err := (<-flushed).err
+	if err == nil {
+		err = fn()
+	}
+	reply <- flushReply[T]{err: err}
+}
+
+// drain hands every buffered item to reply instead of the flusher.
+func (c *consumer[T]) drain(reply chan flushReply[T]) {
+	items := append([]T{}, c.items[:c.count]...)
+	acknowledge(c.acks[:c.count], nil)
+	clear(c.items[:c.count])
+	clear(c.acks[:c.count])
+	c.drop(c.count)
+
+	reply <- flushReply[T]{items: items}
+}
+
+// warmUp ends the initial delay, serving the flush requests that were deferred
+// in the meantime.
+func (c *consumer[T]) warmUp() {
+	c.warming, c.warmup = false, nil
+	for _, request := range c.deferred {
+		c.handle(request)
+	}
+	c.deferred = nil
+}
+
+// add appends an item to the current batch, making room for it first when it
+// would exceed the memory limit.
+func (c *consumer[T]) add(e entry[T]) {
+	buffer := c.buffer
+	item := e.item
+
+	var key any
+	if buffer.LastValueKey != nil {
+		key = buffer.LastValueKey(item)
+		if c.coalesce(key, e) {
+			return
+		}
+	}
+
+	for c.full() {
+		// requeued items filled up the buffer
+		c.flush(c.count, flushRequest[T]{reason: FlushReasonFull})
+	}
+
+	size := 0
+	if buffer.MemoryLimit > 0 {
+		size = buffer.SizeOf(item)
+		for c.count > 0 && c.bytes+size > buffer.MemoryLimit {
+			if buffer.MemoryPolicy == MemoryEvict {
+				c.evict()
+			} else if c.warming {
+				break
+			} else {
+				c.flush(c.count, flushRequest[T]{reason: FlushReasonFull})
+			}
+		}
+	}
+
+	if !c.pushed {
+		c.pushed = true
+		c.buffer.subscribers.emit(Event{Type: EventFirstPush})
+	}
+
+	c.items[c.count] = item
+	c.acks[c.count] = e.ack
+	c.stamps[c.count] = time.Now()
+	c.sizes[c.count] = size
+	c.attempts[c.count] = 0
+	c.ctxs[c.count] = e.ctx
+	c.bytes += size
+	if c.keys != nil {
+		c.keys[key] = c.count
+	}
+	if c.distinct != nil {
+		c.distinct[buffer.DistinctKey(item)] = struct{}{}
+	}
+	c.count++
+	if c.count == 1 {
+		// the buffer was idle until now
+		c.buffer.watchdog.kick()
+	}
+	c.stats.Pushed++
+	c.buffer.Metrics.IncPushed(1)
+	c.updatePending()
+	c.resumeTicker()
+}
+
+// coalesce overwrites the buffered item with the given key in place, keeping its
+// position and timestamp, and reports whether there was one. The superseded item
+// is acknowledged as written.
+func (c *consumer[T]) coalesce(key any, e entry[T]) bool {
+	i, ok := c.index()[key]
+	if !ok {
+		return false
+	}
+
+	size := 0
+	if c.buffer.MemoryLimit > 0 {
+		size = c.buffer.SizeOf(e.item)
+	}
+	superseded := c.acks[i]
+	c.bytes += size - c.sizes[i]
+	c.items[i], c.acks[i], c.sizes[i], c.attempts[i], c.ctxs[i] = e.item, e.ack, size, 0, e.ctx
+	c.stats.Pushed++
+	c.stats.Coalesced++
+	c.buffer.Metrics.IncPushed(1)
+	if superseded != nil {
+		superseded(nil)
+	}
+
+	return true
+}
+
+// index returns the position of every buffered item by key, rebuilding it if
+// items moved since it was last built.
+func (c *consumer[T]) index() map[any]int {
+	if c.keys == nil {
+		c.keys = make(map[any]int, c.count)
+		for i, item := range c.items[:c.count] {
+			c.keys[c.buffer.LastValueKey(item)] = i
+		}
+	}
+
+	return c.keys
+}
+
+// flush writes the first limit items of the current batch and keeps the rest.
+// When the request has a reply channel it receives the outcome of the flush,
+// along with a copy of the flushed items if collect is set.
+func (c *consumer[T]) flush(limit int, request flushRequest[T]) {
+	if c.buffer.MaxInFlightBatches > 0 && c.buffer.OverlappingFlush {
+		limit = c.throttle(limit)
+	}
+	if c.buffer.SkipCancelled {
+		limit = c.skipCancelled(limit)
+	}
+	if c.dedup != nil {
+		limit = c.deduplicate(limit)
+	}
+	if c.rand != nil {
+		limit = c.sample(limit)
+	}
+
+	reply := request.reply
+	if limit == 0 {
+		c.skipped(request.reason)
+		if reply != nil {
+			reply <- flushReply[T]{items: []T{}}
+		}
+		return
+	}
+
+	ctx := request.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if slices.ContainsFunc(c.ctxs[:limit], func(ctx context.Context) bool { return ctx != nil }) {
+		ctx = context.WithValue(ctx, itemContextsKey{}, slices.Clone(c.ctxs[:limit]))
+	}
+
+	buffer := c.buffer
+	batch, acks := c.items[:limit], c.acks[:limit]
+
+	// restart the interval after the flush, unless it should keep its schedule
+	restart := !buffer.StableInterval || request.reason != FlushReasonManual
+	if restart {
+		c.pauseTicker()
+	}
+	c.stats.Flushes++
+	c.stats.Flushed += uint64(limit)
+
+	var flushed []T
+	if request.collect {
+		flushed = append([]T{}, batch...)
+	}
+	result := flushResult[T]{}
+	if buffer.RequeueAttempts > 0 {
+		result.items = batch
+		result.stamps = append([]time.Time(nil), c.stamps[:limit]...)
+		result.attempts = append([]int(nil), c.attempts[:limit]...)
+		result.acks = acks
+		result.ctxs = append([]context.Context(nil), c.ctxs[:limit]...)
+	}
+	var stamps []time.Time
+	if buffer.TimestampedFlusher != nil {
+		stamps = append([]time.Time(nil), c.stamps[:limit]...)
+	}
+	write := func() flushResult[T] {
+		var failed []T
+		failed, result.err = buffer.flush(ctx, batch, stamps, request.reason)
+		acks := acks
+		if result.err != nil && len(failed) < len(batch) {
+			// a partial flusher, a chunk or a group failed, the rest was written
+			acks = settle(&result, batch, acks, failed)
+		}
+		if result.err == nil || !buffer.requeues(result.err) {
+			acknowledge(acks, result.err)
+		}
+		if reply != nil {
+			reply <- flushReply[T]{items: flushed, err: result.err}
+		}
+		return result
+	}
+
+	inline := false
+	switch {
+	case buffer.FlushLaneKey != nil:
+		c.inFlight++
+		key := buffer.FlushLaneKey(batch)
+		prev, done := c.lanes[key], make(chan struct{})
+		c.lanes[key] = done
+		go func() {
+			defer close(done)
+			if prev != nil {
+				<-prev
+			}
+			buffer.resultCh <- write()
+		}()
+	case buffer.OverlappingFlush:
+		c.inFlight++
+		go func() { buffer.resultCh <- write() }()
+	default:
+		buffer.flushing.Store(true)
+		result, inline = write(), true
+		buffer.flushing.Store(false)
+	}
+
+	// keep the items that were not part of the batch
+	remaining := make([]T, buffer.Size)
+	copy(remaining, c.items[limit:c.count])
+	c.items = remaining
+	remainingAcks := make([]func(err error), buffer.Size)
+	copy(remainingAcks, c.acks[limit:c.count])
+	c.acks = remainingAcks
+	c.drop(limit)
+
+	if inline {
+		c.record(result)
+	}
+
+	if restart {
+		c.resumeTicker()
+	}
+}
+
+// skipped reports a flush that had nothing to write.
+func (c *consumer[T]) skipped(reason FlushReason) {
+	if c.buffer.OnEmptyFlush != nil {
+		c.buffer.OnEmptyFlush(reason)
+	}
+}
+
+// pauseTicker stops the interval while the buffer is empty, so an idle buffer
+// does not wake up for nothing.
+func (c *consumer[T]) pauseTicker() {
+	c.stopTicker()
+	c.ticker, c.stopTicker = nil, func() {}
+}
+
+// resumeTicker starts the interval again once the buffer holds items, or right
+// away when the interval must not pause.
+func (c *consumer[T]) resumeTicker() {
+	if c.ticker == nil && (c.count > 0 || !c.pausable()) && !c.buffer.Synchronous {
+		c.ticker, c.stopTicker = newTicker(c.buffer.FlushInterval)
+	}
+}
+
+// pausable reports whether the interval may pause while the buffer is empty,
+// which would move a stable interval off its schedule and hide the empty
+// interval flushes from OnEmptyFlush.
+func (c *consumer[T]) pausable() bool {
+	return !c.buffer.StableInterval && c.buffer.OnEmptyFlush == nil
+}
+
+// throttle blocks until fewer than the maximum number of batches are in flight,
+// returning limit adjusted for the items requeued in the meantime.
+func (c *consumer[T]) throttle(limit int) int {
+	count := c.count
+	for c.inFlight >= c.buffer.MaxInFlightBatches {
+		c.await()
+	}
+
+	return limit + c.count - count
+}
+
+// await blocks until a batch in flight has been written.
+func (c *consumer[T]) await() {
+	c.buffer.flushing.Store(true)
+	defer c.buffer.flushing.Store(false)
+
+	c.complete(<-c.buffer.resultCh)
+}
+
+// settle narrows down the outcome of a flush to the items that could not be
+// written, acknowledging the others as written. It returns the acks of
+// the failed items, and leaves the outcome untouched if the failed items cannot
+// all be matched to the batch.
+func settle[T any](result *flushResult[T], batch []T, acks []func(err error), failed []T) []func(err error) {
+	matched := make([]bool, len(batch))
+	for _, item := range failed {
+		found := false
+		for i, candidate := range batch {
+			if !matched[i] && reflect.DeepEqual(candidate, item) {
+				matched[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return acks
+		}
+	}
+
+	var (
+		written    []func(err error)
+		failedAcks []func(err error)
+		requeued   flushResult[T]
+	)
+	for i, ok := range matched {
+		if !ok {
+			written = append(written, acks[i])
+			continue
+		}
+		failedAcks = append(failedAcks, acks[i])
+		if result.items != nil {
+			requeued.items = append(requeued.items, batch[i])
+			requeued.stamps = append(requeued.stamps, result.stamps[i])
+			requeued.attempts = append(requeued.attempts, result.attempts[i])
+			requeued.acks = append(requeued.acks, acks[i])
+			requeued.ctxs = append(requeued.ctxs, result.ctxs[i])
+		}
+	}
+	acknowledge(written, nil)
+
+	if result.items != nil {
+		requeued.err = result.err
+		*result = requeued
+	}
+
+	return failedAcks
+}
+
+// record accounts for the outcome of a flush, requeueing the batch if it failed
+// and requeueing is enabled.
+func (c *consumer[T]) record(result flushResult[T]) {
+	if result.err == nil {
+		return
+	}
+
+	c.stats.Errors++
+	if c.buffer.requeues(result.err) {
+		c.requeue(result)
+	}
+}
+
+// requeue puts the items of a failed batch back at the front of the buffer,
+// failing the ones that ran out of attempts or no longer fit.
+func (c *consumer[T]) requeue(result flushResult[T]) {
+	buffer := c.buffer
+
+	var (
+		failed     []T
+		failedAcks []func(err error)
+	)
+	var superseded []func(err error)
+	keep := 0
+	for i, item := range result.items {
+		if buffer.LastValueKey != nil {
+			if _, ok := c.index()[buffer.LastValueKey(item)]; ok {
+				// a later item with the same key was pushed in the meantime
+				superseded = append(superseded, result.acks[i])
+				continue
+			}
+		}
+
+		attempts := result.attempts[i] + 1
+		if c.closing || attempts >= buffer.RequeueAttempts || c.count+keep >= len(c.items) {
+			failed = append(failed, item)
+			failedAcks = append(failedAcks, result.acks[i])
+			continue
+		}
+
+		result.items[keep], result.stamps[keep], result.attempts[keep], result.acks[keep], result.ctxs[keep] = item, result.stamps[i], attempts, result.acks[i], result.ctxs[i]
+		keep++
+	}
+
+	if keep > 0 {
+		items := make([]T, buffer.Size)
+		copy(items[keep:], c.items[:c.count])
+		copy(items, result.items[:keep])
+		c.items = items
+		acks := make([]func(err error), buffer.Size)
+		copy(acks[keep:], c.acks[:c.count])
+		copy(acks, result.acks[:keep])
+		c.acks = acks
+
+		copy(c.stamps[keep:], c.stamps[:c.count])
+		copy(c.stamps, result.stamps[:keep])
+		copy(c.sizes[keep:], c.sizes[:c.count])
+		copy(c.attempts[keep:], c.attempts[:c.count])
+		copy(c.attempts, result.attempts[:keep])
+		copy(c.ctxs[keep:], c.ctxs[:c.count])
+		copy(c.ctxs, result.ctxs[:keep])
+		for i, item := range c.items[:keep] {
+			c.sizes[i] = 0
+			if buffer.MemoryLimit > 0 {
+				c.sizes[i] = buffer.SizeOf(item)
+				c.bytes += c.sizes[i]
+			}
+		}
+
+		c.count += keep
+		c.keys = nil
+		c.distinct = nil
+		c.updatePending()
+	}
+	acknowledge(superseded, nil)
+
+	if len(failed) > 0 {
+		buffer.fail(result.err, failed)
+		acknowledge(failedAcks, result.err)
+	}
+}
+
+// skipCancelled drops the first limit items whose context is done, and returns
+// the number of items kept.
+func (c *consumer[T]) skipCancelled(limit int) int {
+	kept := c.retain(limit, func(i int) error { return c.ctxs[i].Err() }, func(i int) bool {
+		return c.ctxs[i] == nil || c.ctxs[i].Err() == nil
+	})
+	c.stats.Cancelled += uint64(limit - kept)
+
+	return kept
+}
+
+// sample keeps each of the first limit items with the configured probability,
+// dropping the others from the buffer, and returns the number of items kept.
+func (c *consumer[T]) sample(limit int) int {
+	kept := c.retain(limit, nil, func(int) bool { return c.rand.Float64() < c.buffer.SampleRate })
+	c.stats.Sampled += uint64(limit - kept)
+
+	return kept
+}
+
+// deduplicate drops the first limit items whose key was flushed within the
+// dedup window, and returns the number of items kept. Requeued items were
+// already seen by definition, so they are always kept.
+func (c *consumer[T]) deduplicate(limit int) int {
+	now := time.Now()
+	c.dedup.expire(now)
+
+	kept := c.retain(limit, nil, func(i int) bool {
+		if c.attempts[i] > 0 {
+			return true
+		}
+
+		key := c.buffer.DedupKey(c.items[i])
+		if c.dedup.seen(key) {
+			return false
+		}
+		c.dedup.add(key, now)
+		return true
+	})
+	c.stats.Deduplicated += uint64(limit - kept)
+
+	return kept
+}
+
+// retain keeps the first limit items for which keep returns true, dropping the
+// others from the buffer while preserving the order of all remaining items, and
+// returns the number of items kept. Dropped items are acknowledged with the error
+// returned by err, or without an error when err is nil.
+func (c *consumer[T]) retain(limit int, err func(i int) error, keep func(i int) bool) int {
+	kept := 0
+	for i := range limit {
+		if !keep(i) {
+			c.bytes -= c.sizes[i]
+			if ack := c.acks[i]; ack != nil {
+				var dropErr error
+				if err != nil {
+					dropErr = err(i)
+				}
+				ack(dropErr)
+			}
+			continue
+		}
+
+		c.items[kept], c.stamps[kept], c.sizes[kept], c.attempts[kept], c.acks[kept], c.ctxs[kept] = c.items[i], c.stamps[i], c.sizes[i], c.attempts[i], c.acks[i], c.ctxs[i]
+		kept++
+	}
+
+	n := limit - kept
+	if n == 0 {
+		return limit
+	}
+
+	copy(c.items[kept:], c.items[limit:c.count])
+	copy(c.stamps[kept:], c.stamps[limit:c.count])
+	copy(c.sizes[kept:], c.sizes[limit:c.count])
+	copy(c.attempts[kept:], c.attempts[limit:c.count])
+	copy(c.acks[kept:], c.acks[limit:c.count])
+	copy(c.ctxs[kept:], c.ctxs[limit:c.count])
+	clear(c.items[c.count-n : c.count])
+	clear(c.acks[c.count-n : c.count])
+	clear(c.ctxs[c.count-n : c.count])
+
+	c.count -= n
+	c.keys = nil
+	c.distinct = nil
+	c.buffer.Metrics.IncDropped(n)
+	c.updatePending()
+
+	return kept
+}
+
+// evict drops the oldest item to free up memory.
+func (c *consumer[T]) evict() {
+	var zero T
+	item, ack := c.items[0], c.acks[0]
+
+	copy(c.items, c.items[1:c.count])
+	copy(c.acks, c.acks[1:c.count])
+	c.items[c.count-1], c.acks[c.count-1] = zero, nil
+	c.drop(1)
+
+	if ack != nil {
+		ack(ErrEvicted)
+	}
+
+	c.buffer.Metrics.IncDropped(1)
+	if c.buffer.OnEvict != nil {
+		c.buffer.OnEvict(item)
+	}
+}
+
+// drop forgets the bookkeeping of the first n items, which must already have
+// been removed from items.
+func (c *consumer[T]) drop(n int) {
+	for _, size := range c.sizes[:n] {
+		c.bytes -= size
+	}
+	copy(c.stamps, c.stamps[n:c.count])
+	copy(c.sizes, c.sizes[n:c.count])
+	copy(c.attempts, c.attempts[n:c.count])
+	copy(c.ctxs, c.ctxs[n:c.count])
+	clear(c.ctxs[c.count-n : c.count])
+
+	c.count -= n
+	c.keys = nil
+	c.distinct = nil
+	c.updatePending()
+}
+
+// complete records the outcome of a flush that ran on its own goroutine.
+func (c *consumer[T]) complete(result flushResult[T]) {
+	c.inFlight--
+	c.record(result)
+	if c.inFlight == 0 {
+		clear(c.lanes)
+	}
+}
+
+// reschedule consults the size schedule for the flush threshold, flushing the
+// buffered items right away when the threshold dropped to or below their
+// number.
+func (c *consumer[T]) reschedule() {
+	if c.buffer.SizeSchedule == nil {
+		return
+	}
+	c.limit = c.buffer.threshold()
+	if c.count > 0 && !c.warming && !c.buffer.NoFlushOnFull && c.full() {
+		c.flush(c.count, flushRequest[T]{reason: FlushReasonFull})
+	}
+}
+
+// full reports whether the buffer holds Size items, or as many items as the
+// size schedule's threshold, or items for as many distinct keys as the distinct
+// key trigger. The check happens right
+// after an item is added, within the same iteration of the consume loop, so the
+// push that fills the buffer always triggers the flush and its item is always
+// part of the flushed batch.
+func (c *consumer[T]) full() bool {
+	return c.count >= c.limit || c.buffer.DistinctKeys > 0 && len(c.distinctKeys()) >= c.buffer.DistinctKeys
+}
+
+// distinctKeys returns the set of keys of the buffered items, rebuilding it if
+// items moved since it was last built.
+func (c *consumer[T]) distinctKeys() map[any]struct{} {
+	if c.distinct == nil {
+		c.distinct = make(map[any]struct{}, c.count)
+		for _, item := range c.items[:c.count] {
+			c.distinct[c.buffer.DistinctKey(item)] = struct{}{}
+		}
+	}
+
+	return c.distinct
+}
+
+// threshold returns the number of items that fills the buffer.
+func (buffer *Buffer[T]) threshold() int {
+	if buffer.SizeSchedule == nil {
+		return int(buffer.Size)
+	}
+	now := time.Now
+	if buffer.Clock != nil {
+		now = buffer.Clock
+	}
+	size := buffer.SizeSchedule(now())
+	if size == 0 || size > buffer.Size {
+		size = buffer.Size
+	}
+	return int(size)
+}
+
+func (c *consumer[T]) updatePending() {
+	c.buffer.pending.Store(int64(c.count))
+	if c.count == 0 {
+		c.buffer.emptied.notify()
+	}
+	c.buffer.pressure.update(uint(c.count), c.high, c.low)
+}
+
+// close waits for in-flight flushes and releases everything tied to the buffer.
+func (c *consumer[T]) close() {
+	buffer := c.buffer
+
+	c.stopTicker()
+	if c.debounceTimer != nil {
+		c.debounceTimer.Stop()
+	}
+	if c.count > 0 {
+		buffer.discarded = c.items[:c.count]
+		acknowledge(c.acks[:c.count], ErrClosed)
+	}
+	for c.inFlight > 0 {
+		c.complete(<-buffer.resultCh)
+	}
+	buffer.watchdog.stop()
+	if buffer.CloseFlusher {
+		buffer.closeErr = buffer.closeFlushers()
+	}
+	if buffer.OnClose != nil {
+		buffer.OnClose()
+	}
+	buffer.subscribers.emit(Event{Type: EventClosed})
+	buffer.subscribers.closeAll()
+	if buffer.ErrChSize > 0 {
+		buffer.errCh.close(buffer.ErrChSize)
+	}
+	buffer.pressure.close()
+	close(buffer.doneCh)
+}
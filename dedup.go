@@ -0,0 +1,61 @@
+package buffer
+
+import "time"
+
+// dedupCapacity bounds the number of keys remembered by a dedup window, the
+// oldest keys are forgotten first once it is reached.
+const dedupCapacity = 1 << 16
+
+type (
+	// dedupWindow remembers the keys that were flushed within a time window. It
+	// is owned by the consume goroutine.
+	dedupWindow struct {
+		window time.Duration
+		keys   map[any]time.Time
+		order  []dedupEntry
+	}
+
+	dedupEntry struct {
+		key any
+		at  time.Time
+	}
+)
+
+func newDedupWindow(window time.Duration) *dedupWindow {
+	return &dedupWindow{
+		window: window,
+		keys:   map[any]time.Time{},
+	}
+}
+
+// seen reports whether key was flushed within the window.
+func (d *dedupWindow) seen(key any) bool {
+	_, ok := d.keys[key]
+	return ok
+}
+
+// add records that key was flushed at the given time.
+func (d *dedupWindow) add(key any, at time.Time) {
+	if len(d.order) >= dedupCapacity {
+		d.forget()
+	}
+
+	d.keys[key] = at
+	d.order = append(d.order, dedupEntry{key: key, at: at})
+}
+
+// expire forgets the keys that were flushed longer than the window ago.
+func (d *dedupWindow) expire(now time.Time) {
+	for len(d.order) > 0 && now.Sub(d.order[0].at) > d.window {
+		d.forget()
+	}
+}
+
+// forget drops the oldest entry.
+func (d *dedupWindow) forget() {
+	entry := d.order[0]
+	d.order = d.order[1:]
+	if at, ok := d.keys[entry.key]; ok && at.Equal(entry.at) {
+		delete(d.keys, entry.key)
+	}
+}
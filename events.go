@@ -0,0 +1,289 @@
+package buffer
+
+import (
+	"context"
+	"sync"
+)
+
+const subscriberBufferSize = 16
+
+const (
+	// EventInitialized is emitted once the buffer has been initialized.
+	EventInitialized EventType = iota
+	// EventFirstPush is emitted when the first item is accepted by the buffer.
+	EventFirstPush
+	// EventFlushStarted is emitted right before a batch is written.
+	EventFlushStarted
+	// EventFlushCompleted is emitted right after a batch has been written.
+	EventFlushCompleted
+	// EventClosing is emitted when the buffer starts closing.
+	EventClosing
+	// EventClosed is emitted once the buffer is fully closed.
+	EventClosed
+)
+
+const (
+	// FlushReasonManual indicates a flush requested through Flush or one of its
+	// variants.
+	FlushReasonManual FlushReason = iota
+	// FlushReasonFull indicates the buffer filled up, either in number of items
+	// or in memory.
+	FlushReasonFull
+	// FlushReasonInterval indicates the flush interval elapsed.
+	FlushReasonInterval
+	// FlushReasonPriority indicates a priority item was pushed.
+	FlushReasonPriority
+	// FlushReasonClose indicates the final flush of a closing buffer.
+	FlushReasonClose
+	// FlushReasonWarmUp indicates the initial delay elapsed.
+	FlushReasonWarmUp
+	// FlushReasonTrigger indicates the flush trigger fired, see
+	// WithFlushTrigger.
+	FlushReasonTrigger
+)
+
+type (
+	// EventType identifies a buffer lifecycle transition.
+	EventType int
+
+	// FlushReason identifies what triggered a flush.
+	FlushReason int
+
+	// Event describes a buffer lifecycle transition.
+	Event struct {
+		Type EventType
+		// Size is the number of items in the batch for flush events.
+		Size int
+		// Reason is what triggered the flush for flush events.
+		Reason FlushReason
+		// Err is the error the transition resulted in, if any.
+		Err error
+	}
+
+	flushReasonKey  struct{}
+	itemContextsKey struct{}
+
+	subscribers struct {
+		mu     sync.Mutex
+		subs   map[chan Event]struct{}
+		closed bool
+	}
+)
+
+func (reason FlushReason) String() string {
+	switch reason {
+	case FlushReasonManual:
+		return "manual"
+	case FlushReasonFull:
+		return "full"
+	case FlushReasonInterval:
+		return "interval"
+	case FlushReasonPriority:
+		return "priority"
+	case FlushReasonClose:
+		return "close"
+	case FlushReasonWarmUp:
+		return "warm-up"
+	case FlushReasonTrigger:
+		return "trigger"
+	default:
+		return "unknown"
+	}
+}
+
+// FlushReasonFromContext returns what triggered the flush a context-aware
+// flusher is writing a batch for, and false if ctx does not belong to a flush.
+func FlushReasonFromContext(ctx context.Context) (FlushReason, bool) {
+	reason, ok := ctx.Value(flushReasonKey{}).(FlushReason)
+	return reason, ok
+}
+
+// ItemContextsFromContext returns the contexts the items of the flushed batch
+// were pushed with by PushWithAckContext, for a context-aware flusher to link
+// the write to the pushes. They cover the whole batch in push order, before
+// any batch transform, grouping or chunking, with a nil context for every item
+// pushed without one. It returns nil if no item of the batch was pushed with a
+// context.
+func ItemContextsFromContext(ctx context.Context) []context.Context {
+	ctxs, _ := ctx.Value(itemContextsKey{}).([]context.Context)
+	return ctxs
+}
+
+// Subscribe registers a new observer of the buffer's lifecycle events.
+//
+// It returns a channel on which events are delivered and a function that
+// unsubscribes and closes the channel. Events are dropped rather than delivered
+// when the channel is full, so a slow observer never blocks the buffer. All
+// channels are closed once the buffer is closed.
+func (buffer *Buffer[T]) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBufferSize)
+
+	buffer.subscribers.mu.Lock()
+	defer buffer.subscribers.mu.Unlock()
+
+	if buffer.subscribers.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if buffer.subscribers.subs == nil {
+		buffer.subscribers.subs = make(map[chan Event]struct{})
+	}
+	buffer.subscribers.subs[ch] = struct{}{}
+
+	return ch, func() { buffer.subscribers.remove(ch) }
+}
+
+func (s *subscribers) remove(ch chan Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[ch]; ok {
+		delete(s.subs, ch)
+		close(ch)
+	}
+}
+
+func (s *subscribers) emit(event Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+func (s *subscribers) closeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for ch := range s.subs {
+		delete(s.subs, ch)
+		close(ch)
+	}
+}
+
+type flushDone struct {
+	once sync.Once
+	ch   chan struct{}
+}
+
+// FlushDone returns a channel that receives a value after every completed
+// flush, which is mostly useful to synchronize tests with the buffer.
+//
+// Pulses are coalesced: the channel holds at most one value. It returns a nil
+// channel unless WithFlushDoneSignal is set.
+func (buffer *Buffer[T]) FlushDone() <-chan struct{} {
+	if !buffer.FlushDoneSignal {
+		return nil
+	}
+
+	return buffer.flushDone.signal()
+}
+
+func (f *flushDone) signal() chan struct{} {
+	f.once.Do(func() {
+		f.ch = make(chan struct{}, 1)
+	})
+
+	return f.ch
+}
+
+func (f *flushDone) pulse() {
+	select {
+	case f.signal() <- struct{}{}:
+	default:
+	}
+}
+
+// emptied is closed and replaced every time the buffer becomes empty, so that
+// any number of goroutines can wait for it, see WaitEmpty.
+type emptied struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+func (e *emptied) wait() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.ch == nil {
+		e.ch = make(chan struct{})
+	}
+
+	return e.ch
+}
+
+func (e *emptied) notify() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.ch != nil {
+		close(e.ch)
+		e.ch = nil
+	}
+}
+
+type errorChannel struct {
+	once   sync.Once
+	mu     sync.Mutex
+	ch     chan error
+	closed bool
+}
+
+// Errors returns a channel on which the error of every flush that fails is
+// published as it happens, as an alternative to WithErrorHandler.
+//
+// When the channel is full, the oldest error is dropped to make room for the
+// new one, so a slow reader never blocks the buffer. The channel is closed once
+// the buffer is closed. It returns a nil channel unless WithErrCh is set.
+func (buffer *Buffer[T]) Errors() <-chan error {
+	if buffer.ErrChSize == 0 {
+		return nil
+	}
+
+	return buffer.errCh.channel(buffer.ErrChSize)
+}
+
+func (e *errorChannel) channel(size int) chan error {
+	e.once.Do(func() {
+		e.ch = make(chan error, size)
+	})
+
+	return e.ch
+}
+
+func (e *errorChannel) publish(size int, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return
+	}
+
+	ch := e.channel(size)
+	for {
+		select {
+		case ch <- err:
+			return
+		default:
+		}
+
+		// drop the oldest error
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
+
+func (e *errorChannel) close(size int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.closed = true
+	close(e.channel(size))
+}
@@ -0,0 +1,291 @@
+package buffer_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/omniboost/go-buffer"
+)
+
+var _ = Describe("Events", func() {
+	var flusher *MockFlusher[any]
+
+	BeforeEach(func() {
+		flusher = NewMockFlusher[any]()
+	})
+
+	It("delivers lifecycle events to subscribers", func() {
+		// arrange
+		sut := buffer.New[any]().
+			WithSize(2).
+			WithFlusher(flusher)
+
+		events, _ := sut.Subscribe()
+
+		// act
+		err := sut.Push(1)
+		err1 := sut.Close()
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(err1).To(Succeed())
+
+		var types []buffer.EventType
+		for event := range events {
+			types = append(types, event.Type)
+			if event.Type == buffer.EventFlushStarted {
+				Expect(event.Size).To(Equal(1))
+			}
+		}
+		Expect(types).To(Equal([]buffer.EventType{
+			buffer.EventInitialized,
+			buffer.EventFirstPush,
+			buffer.EventClosing,
+			buffer.EventFlushStarted,
+			buffer.EventFlushCompleted,
+			buffer.EventClosed,
+		}))
+	})
+
+	It("supports multiple independent subscribers", func() {
+		// arrange
+		sut := buffer.New[any]().
+			WithSize(2).
+			WithFlusher(flusher)
+
+		events1, _ := sut.Subscribe()
+		events2, _ := sut.Subscribe()
+
+		// act
+		err := sut.Push(1)
+
+		// assert
+		Expect(err).To(Succeed())
+		Eventually(events1).Should(Receive(Equal(buffer.Event{Type: buffer.EventInitialized})))
+		Eventually(events2).Should(Receive(Equal(buffer.Event{Type: buffer.EventInitialized})))
+		_ = sut.Close()
+	})
+
+	It("stops delivery after unsubscribing", func() {
+		// arrange
+		sut := buffer.New[any]().
+			WithSize(2).
+			WithFlusher(flusher)
+
+		events, unsubscribe := sut.Subscribe()
+
+		// act
+		unsubscribe()
+		err := sut.Push(1)
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(events).To(BeClosed())
+		_ = sut.Close()
+	})
+
+	It("returns a closed channel when subscribing to a closed buffer", func() {
+		// arrange
+		sut := buffer.New[any]().
+			WithSize(2).
+			WithFlusher(flusher)
+
+		_ = sut.Push(1)
+		_ = sut.Close()
+
+		// act
+		events, _ := sut.Subscribe()
+
+		// assert
+		Expect(events).To(BeClosed())
+	})
+
+	It("reports what triggered every flush", func() {
+		// arrange
+		sut := buffer.New[int]().
+			WithSize(2).
+			WithFlusher(buffer.FlusherFunc[int](func([]int) error { return nil }))
+
+		events, _ := sut.Subscribe()
+
+		// act
+		err := sut.Push(1)
+		_ = sut.Push(2)
+		_ = sut.Push(3)
+		_ = sut.Flush()
+		_ = sut.Push(4)
+		_ = sut.Close()
+
+		// assert
+		Expect(err).To(Succeed())
+		var reasons []buffer.FlushReason
+		for event := range events {
+			if event.Type == buffer.EventFlushStarted {
+				reasons = append(reasons, event.Reason)
+			}
+		}
+		Expect(reasons).To(Equal([]buffer.FlushReason{
+			buffer.FlushReasonFull,
+			buffer.FlushReasonManual,
+			buffer.FlushReasonClose,
+		}))
+		Expect(buffer.FlushReasonFull.String()).To(Equal("full"))
+	})
+
+	It("hands the flush reason to context-aware flushers", func() {
+		// arrange
+		reasons := make(chan buffer.FlushReason, 1)
+		sut := buffer.New[int]().
+			WithSize(2).
+			WithContextFlusher(buffer.ContextFlusherFunc[int](func(ctx context.Context, items []int) error {
+				reason, _ := buffer.FlushReasonFromContext(ctx)
+				reasons <- reason
+				return nil
+			}))
+
+		// act
+		err := sut.Push(1)
+		_ = sut.Push(2)
+
+		// assert
+		Expect(err).To(Succeed())
+		Eventually(reasons).Should(Receive(Equal(buffer.FlushReasonFull)))
+		_, ok := buffer.FlushReasonFromContext(context.Background())
+		Expect(ok).To(BeFalse())
+		_ = sut.Close()
+	})
+
+	It("reports flushes that have nothing to write", func() {
+		// arrange
+		reasons := make(chan buffer.FlushReason, 2)
+		sut := buffer.New[int]().
+			WithSize(2).
+			WithFlusher(buffer.FlusherFunc[int](func([]int) error { return nil })).
+			WithOnEmptyFlush(func(reason buffer.FlushReason) { reasons <- reason })
+
+		err := sut.Push(1)
+		_ = sut.Push(2)
+
+		// act
+		err1 := sut.FlushAndWait(context.Background())
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(err1).To(Succeed())
+		Expect(reasons).To(Receive(Equal(buffer.FlushReasonManual)))
+		_ = sut.Close()
+		Expect(reasons).To(Receive(Equal(buffer.FlushReasonClose)))
+	})
+
+	It("reports every empty interval flush while the buffer is idle", func() {
+		// arrange
+		reasons := make(chan buffer.FlushReason, 10)
+		sut := buffer.New[int]().
+			WithSize(2).
+			WithFlusher(buffer.FlusherFunc[int](func([]int) error { return nil })).
+			WithFlushInterval(50 * time.Millisecond).
+			WithOnEmptyFlush(func(reason buffer.FlushReason) { reasons <- reason })
+
+		// act
+		err := sut.Push(1)
+
+		// assert
+		Expect(err).To(Succeed())
+		for range 3 {
+			Eventually(reasons).Should(Receive(Equal(buffer.FlushReasonInterval)))
+		}
+		_ = sut.Close()
+	})
+
+	Context("FlushDone", func() {
+		It("pulses after every completed flush", func() {
+			// arrange
+			sut := buffer.New[int]().
+				WithSize(1).
+				WithFlusher(buffer.FlusherFunc[int](func([]int) error { return nil })).
+				WithFlushDoneSignal()
+
+			done := sut.FlushDone()
+
+			// act
+			err := sut.Push(1)
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(done).Should(Receive())
+			Expect(sut.Push(2)).To(Succeed())
+			Eventually(done).Should(Receive())
+			_ = sut.Close()
+		})
+
+		It("returns a nil channel unless enabled", func() {
+			// arrange
+			sut := buffer.New[any]()
+
+			// act
+			done := sut.FlushDone()
+
+			// assert
+			Expect(done).To(BeNil())
+		})
+	})
+
+	Context("Errors", func() {
+		It("publishes every flush error and closes with the buffer", func() {
+			// arrange
+			sut := buffer.New[int]().
+				WithSize(1).
+				WithFlusher(buffer.FlusherFunc[int](func(items []int) error {
+					return fmt.Errorf("sink is down for %d", items[0])
+				})).
+				WithErrCh(4)
+
+			errs := sut.Errors()
+
+			// act
+			err := sut.Push(1)
+			_ = sut.Push(2)
+			_ = sut.Close()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(errs).To(Receive(MatchError("sink is down for 1")))
+			Expect(errs).To(Receive(MatchError("sink is down for 2")))
+			Expect(errs).To(BeClosed())
+		})
+
+		It("drops the oldest error when the channel is full", func() {
+			// arrange
+			sut := buffer.New[int]().
+				WithSize(1).
+				WithFlusher(buffer.FlusherFunc[int](func(items []int) error {
+					return fmt.Errorf("sink is down for %d", items[0])
+				})).
+				WithErrCh(1)
+
+			// act
+			err := sut.Push(1)
+			_ = sut.Push(2)
+			_ = sut.Close()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(sut.Errors()).To(Receive(MatchError("sink is down for 2")))
+		})
+
+		It("returns a nil channel unless enabled", func() {
+			// arrange
+			sut := buffer.New[any]()
+
+			// act
+			errs := sut.Errors()
+
+			// assert
+			Expect(errs).To(BeNil())
+		})
+	})
+})
@@ -1,15 +1,297 @@
 package buffer
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
 type (
 	// Flusher represents a destination of buffered data.
+	//
+	// By default the slice passed to Write is a view of the buffer's internal
+	// storage, which the buffer replaces with a new allocation after every
+	// flush. A flusher that retains the slice beyond Write should enable
+	// CopyOnFlush rather than rely on that implementation detail.
+	//
+	// A non-nil error returned by Write is passed to the buffer's error handler.
 	Flusher[T any] interface {
-		Write(items []T)
+		Write(items []T) error
 	}
 
 	// FlusherFunc represents a flush function.
-	FlusherFunc[T any] func(items []T)
+	FlusherFunc[T any] func(items []T) error
+
+	// ContextFlusher represents a destination of buffered data that receives the
+	// context of the flush that produced the batch, so it can propagate
+	// deadlines and tracing to the actual write.
+	ContextFlusher[T any] interface {
+		Write(ctx context.Context, items []T) error
+	}
+
+	// ContextFlusherFunc represents a context-aware flush function.
+	ContextFlusherFunc[T any] func(ctx context.Context, items []T) error
+
+	// TimestampedItem represents a buffered item along with the time it was
+	// pushed.
+	TimestampedItem[T any] struct {
+		Item     T
+		PushedAt time.Time
+	}
+
+	// TimestampedFlusher represents a destination of buffered data that
+	// receives the push time of every item, see WithTimestamps.
+	TimestampedFlusher[T any] interface {
+		WriteTimestamped(items []TimestampedItem[T]) error
+	}
+
+	// PartialFlusher represents a destination of buffered data that can write
+	// part of a batch, reporting the items it failed to write, see
+	// WithPartialFlusher.
+	PartialFlusher[T any] interface {
+		Write(items []T) (failed []T, err error)
+	}
+
+	// PartialFlusherFunc represents a partial flush function.
+	PartialFlusherFunc[T any] func(items []T) (failed []T, err error)
+
+	// TimestampedFlusherFunc represents a timestamped flush function.
+	TimestampedFlusherFunc[T any] func(items []TimestampedItem[T]) error
+
+	// ChannelFlusher represents a flusher that sends every batch to a channel.
+	ChannelFlusher[T any] struct {
+		Ch      chan<- []T
+		Timeout time.Duration
+	}
+
+	// ReplayFlusher represents a flusher that forwards every batch to another
+	// flusher, and retains the most recent batches in memory so they can be
+	// replayed, for instance for debugging or re-delivery after a downstream
+	// consumer reconnects.
+	ReplayFlusher[T any] struct {
+		Next Flusher[T]
+
+		mu      sync.Mutex
+		batches [][]T
+		next    int
+		full    bool
+	}
+
+	// CollectFlusher represents a flusher that records every batch it is handed
+	// over its lifetime, for instance to assert on in tests. It is safe to read
+	// the recorded batches while the buffer keeps flushing.
+	CollectFlusher[T any] struct {
+		mu      sync.Mutex
+		batches [][]T
+	}
 )
 
-func (fn FlusherFunc[T]) Write(items []T) {
-	fn(items)
+func (fn FlusherFunc[T]) Write(items []T) error {
+	return fn(items)
+}
+
+func (fn ContextFlusherFunc[T]) Write(ctx context.Context, items []T) error {
+	return fn(ctx, items)
+}
+
+func (fn PartialFlusherFunc[T]) Write(items []T) ([]T, error) {
+	return fn(items)
+}
+
+func (fn TimestampedFlusherFunc[T]) WriteTimestamped(items []TimestampedItem[T]) error {
+	return fn(items)
+}
+
+// timestamp pairs every item with its push time, leaving it zero when there are
+// no push times.
+func timestamp[T any](items []T, stamps []time.Time) []TimestampedItem[T] {
+	timestamped := make([]TimestampedItem[T], len(items))
+	for i, item := range items {
+		timestamped[i].Item = item
+		if stamps != nil {
+			timestamped[i].PushedAt = stamps[i]
+		}
+	}
+
+	return timestamped
+}
+
+// NewChannelFlusher creates a flusher that sends every batch to ch.
+//
+// When the channel is full, Write blocks until the batch is received if timeout
+// is zero. Otherwise it waits up to timeout and then drops the batch, returning
+// an ErrTimeout.
+func NewChannelFlusher[T any](ch chan<- []T, timeout time.Duration) *ChannelFlusher[T] {
+	return &ChannelFlusher[T]{
+		Ch:      ch,
+		Timeout: timeout,
+	}
+}
+
+// NewReplayFlusher creates a flusher that forwards every batch to next and
+// retains the last capacity batches, regardless of whether next wrote them
+// successfully.
+func NewReplayFlusher[T any](next Flusher[T], capacity int) *ReplayFlusher[T] {
+	return &ReplayFlusher[T]{
+		Next:    next,
+		batches: make([][]T, capacity),
+	}
+}
+
+func (flusher *ReplayFlusher[T]) Write(items []T) error {
+	if len(flusher.batches) > 0 {
+		flusher.mu.Lock()
+		flusher.batches[flusher.next] = append([]T(nil), items...)
+		flusher.next = (flusher.next + 1) % len(flusher.batches)
+		flusher.full = flusher.full || flusher.next == 0
+		flusher.mu.Unlock()
+	}
+
+	return flusher.Next.Write(items)
+}
+
+// Replay calls fn with every retained batch, from the oldest to the most
+// recent one. The batches are copies, so fn is free to retain them.
+func (flusher *ReplayFlusher[T]) Replay(fn func(batch []T)) {
+	for _, batch := range flusher.retained() {
+		fn(batch)
+	}
+}
+
+// Last returns the most recent batch, or nil if nothing has been written yet.
+func (flusher *ReplayFlusher[T]) Last() []T {
+	batches := flusher.retained()
+	if len(batches) == 0 {
+		return nil
+	}
+
+	return batches[len(batches)-1]
+}
+
+// retained returns the retained batches in the order they were written.
+func (flusher *ReplayFlusher[T]) retained() [][]T {
+	flusher.mu.Lock()
+	defer flusher.mu.Unlock()
+
+	if !flusher.full {
+		return append([][]T(nil), flusher.batches[:flusher.next]...)
+	}
+
+	return append(append([][]T(nil), flusher.batches[flusher.next:]...), flusher.batches[:flusher.next]...)
+}
+
+// NewCollectFlusher creates a flusher that records every batch.
+func NewCollectFlusher[T any]() *CollectFlusher[T] {
+	return &CollectFlusher[T]{}
+}
+
+func (flusher *CollectFlusher[T]) Write(items []T) error {
+	flusher.mu.Lock()
+	defer flusher.mu.Unlock()
+
+	flusher.batches = append(flusher.batches, append([]T(nil), items...))
+	return nil
+}
+
+// Batches returns a copy of every recorded batch, in the order they were
+// written.
+func (flusher *CollectFlusher[T]) Batches() [][]T {
+	flusher.mu.Lock()
+	defer flusher.mu.Unlock()
+
+	batches := make([][]T, len(flusher.batches))
+	for i, batch := range flusher.batches {
+		batches[i] = append([]T(nil), batch...)
+	}
+
+	return batches
+}
+
+// Items returns every recorded item, in the order they were written.
+func (flusher *CollectFlusher[T]) Items() []T {
+	flusher.mu.Lock()
+	defer flusher.mu.Unlock()
+
+	var items []T
+	for _, batch := range flusher.batches {
+		items = append(items, batch...)
+	}
+
+	return items
+}
+
+// TeeFlusher creates a flusher that writes every batch to primary, and mirrors
+// it to secondary once primary has returned, for instance to tap into the data
+// for debugging or to send shadow traffic. Only the error of primary is
+// returned: errors of secondary are ignored, so it never affects the buffer.
+func TeeFlusher[T any](primary, secondary Flusher[T]) Flusher[T] {
+	return FlusherFunc[T](func(items []T) error {
+		err := primary.Write(items)
+		_ = secondary.Write(items)
+
+		return err
+	})
+}
+
+// TypeRoutingFlusher creates a partial flusher for buffers of mixed item types,
+// see WithPartialFlusher, which splits every batch by the concrete type of its
+// items and writes every part to the flusher routed to that type, as obtained
+// with reflect.TypeFor for instance. Parts keep the order of their items, and
+// are written in the order their types first appear in the batch.
+//
+// Items of types without a route are written to fallback, or failed with an
+// ErrNoFlusher when fallback is nil. Every part is written even when another
+// failed. Only the items of the parts that failed are reported as failed, along
+// with the errors of those parts joined, so retries and requeues do not write
+// the other parts again.
+func TypeRoutingFlusher(routes map[reflect.Type]Flusher[any], fallback Flusher[any]) PartialFlusher[any] {
+	return PartialFlusherFunc[any](func(items []any) ([]any, error) {
+		var types []reflect.Type
+		parts := map[reflect.Type][]any{}
+		for _, item := range items {
+			t := reflect.TypeOf(item)
+			if _, ok := parts[t]; !ok {
+				types = append(types, t)
+			}
+			parts[t] = append(parts[t], item)
+		}
+
+		var (
+			failed []any
+			errs   []error
+		)
+		for _, t := range types {
+			flusher, ok := routes[t]
+			if !ok {
+				flusher = fallback
+			}
+			err := fmt.Errorf("%w: %d items of type %v", ErrNoFlusher, len(parts[t]), t)
+			if flusher != nil {
+				err = flusher.Write(parts[t])
+			}
+			if err != nil {
+				failed = append(failed, parts[t]...)
+				errs = append(errs, err)
+			}
+		}
+
+		return failed, errors.Join(errs...)
+	})
+}
+
+func (flusher *ChannelFlusher[T]) Write(items []T) error {
+	if flusher.Timeout == 0 {
+		flusher.Ch <- items
+		return nil
+	}
+
+	select {
+	case flusher.Ch <- items:
+		return nil
+	case <-time.After(flusher.Timeout):
+		return errors.Join(errors.New("channel is full"), ErrTimeout)
+	}
 }
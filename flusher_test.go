@@ -0,0 +1,226 @@
+package buffer_test
+
+import (
+	"errors"
+	"reflect"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/omniboost/go-buffer"
+)
+
+var _ = Describe("Flushers", func() {
+	Context("ChannelFlusher", func() {
+		It("sends every batch to the channel", func() {
+			// arrange
+			ch := make(chan []int, 1)
+			sut := buffer.New[int]().
+				WithSize(2).
+				WithFlusher(buffer.NewChannelFlusher[int](ch, 0))
+
+			// act
+			err := sut.Push(1)
+			_ = sut.Push(2)
+
+			// assert
+			Expect(err).To(Succeed())
+			Eventually(ch).Should(Receive(Equal([]int{1, 2})))
+			_ = sut.Close()
+		})
+
+		It("drops the batch when the channel is full past the timeout", func() {
+			// arrange
+			ch := make(chan []int)
+			sut := buffer.NewChannelFlusher[int](ch, 10*time.Millisecond)
+
+			// act
+			err := sut.Write([]int{1, 2})
+
+			// assert
+			Expect(err).To(MatchError(buffer.ErrTimeout))
+		})
+	})
+
+	Context("ReplayFlusher", func() {
+		It("forwards every batch to the wrapped flusher", func() {
+			// arrange
+			ch := make(chan []int, 1)
+			sut := buffer.NewReplayFlusher[int](buffer.NewChannelFlusher[int](ch, 0), 2)
+
+			// act
+			err := sut.Write([]int{1, 2})
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(ch).To(Receive(Equal([]int{1, 2})))
+		})
+
+		It("replays the most recent batches in order", func() {
+			// arrange
+			sut := buffer.NewReplayFlusher[int](buffer.FlusherFunc[int](func([]int) error { return nil }), 2)
+
+			_ = sut.Write([]int{1})
+			_ = sut.Write([]int{2})
+			_ = sut.Write([]int{3})
+
+			// act
+			var replayed [][]int
+			sut.Replay(func(batch []int) { replayed = append(replayed, batch) })
+
+			// assert
+			Expect(replayed).To(Equal([][]int{{2}, {3}}))
+			Expect(sut.Last()).To(Equal([]int{3}))
+		})
+
+		It("returns no last batch before anything was written", func() {
+			// arrange
+			sut := buffer.NewReplayFlusher[int](buffer.FlusherFunc[int](func([]int) error { return nil }), 2)
+
+			// act
+			last := sut.Last()
+
+			// assert
+			Expect(last).To(BeNil())
+		})
+	})
+
+	Context("CollectFlusher", func() {
+		It("records every batch the buffer flushed", func() {
+			// arrange
+			collector := buffer.NewCollectFlusher[int]()
+			sut := buffer.New[int]().
+				WithSize(2).
+				WithFlusher(collector)
+
+			// act
+			err := sut.Push(1)
+			_ = sut.Push(2)
+			_ = sut.Push(3)
+			Eventually(func() [][]int { return collector.Batches() }).Should(HaveLen(1))
+			_ = sut.Close()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(collector.Batches()).To(Equal([][]int{{1, 2}, {3}}))
+			Expect(collector.Items()).To(Equal([]int{1, 2, 3}))
+		})
+
+		It("returns copies of the recorded batches", func() {
+			// arrange
+			sut := buffer.NewCollectFlusher[int]()
+			items := []int{1, 2}
+			_ = sut.Write(items)
+
+			// act
+			items[0] = 3
+			sut.Batches()[0][1] = 4
+
+			// assert
+			Expect(sut.Batches()).To(Equal([][]int{{1, 2}}))
+		})
+	})
+
+	Context("TeeFlusher", func() {
+		It("mirrors every batch to the secondary flusher", func() {
+			// arrange
+			primary, secondary := make(chan []int, 1), make(chan []int, 1)
+			sut := buffer.TeeFlusher[int](buffer.NewChannelFlusher[int](primary, 0), buffer.NewChannelFlusher[int](secondary, 0))
+
+			// act
+			err := sut.Write([]int{1, 2})
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(primary).To(Receive(Equal([]int{1, 2})))
+			Expect(secondary).To(Receive(Equal([]int{1, 2})))
+		})
+
+		It("only returns the error of the primary flusher", func() {
+			// arrange
+			ok := buffer.FlusherFunc[int](func([]int) error { return nil })
+			failing := buffer.FlusherFunc[int](func([]int) error { return errors.New("sink is down") })
+
+			// act
+			err := buffer.TeeFlusher[int](ok, failing).Write([]int{1})
+			err1 := buffer.TeeFlusher[int](failing, ok).Write([]int{1})
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(MatchError("sink is down"))
+		})
+	})
+
+	Context("TypeRoutingFlusher", func() {
+		It("routes items to the flusher of their type", func() {
+			// arrange
+			ints, strings, others := make(chan []any, 1), make(chan []any, 1), make(chan []any, 1)
+			sut := buffer.TypeRoutingFlusher(map[reflect.Type]buffer.Flusher[any]{
+				reflect.TypeFor[int]():    buffer.NewChannelFlusher[any](ints, 0),
+				reflect.TypeFor[string](): buffer.NewChannelFlusher[any](strings, 0),
+			}, buffer.NewChannelFlusher[any](others, 0))
+
+			// act
+			failed, err := sut.Write([]any{1, "a", 2.5, 2, "b"})
+
+			// assert
+			Expect(failed).To(BeEmpty())
+			Expect(err).To(Succeed())
+			Expect(ints).To(Receive(Equal([]any{1, 2})))
+			Expect(strings).To(Receive(Equal([]any{"a", "b"})))
+			Expect(others).To(Receive(Equal([]any{2.5})))
+		})
+
+		It("fails items without a route when there is no fallback", func() {
+			// arrange
+			ints := make(chan []any, 1)
+			sut := buffer.TypeRoutingFlusher(map[reflect.Type]buffer.Flusher[any]{
+				reflect.TypeFor[int](): buffer.NewChannelFlusher[any](ints, 0),
+			}, nil)
+
+			// act
+			failed, err := sut.Write([]any{1, "a"})
+
+			// assert
+			Expect(failed).To(Equal([]any{"a"}))
+			Expect(err).To(MatchError(buffer.ErrNoFlusher))
+			Expect(err).To(MatchError(ContainSubstring("string")))
+			Expect(ints).To(Receive(Equal([]any{1})))
+		})
+
+		It("retries only the parts that failed", func() {
+			// arrange
+			var ints, strings [][]any
+			attempts := 0
+			sut := buffer.New[any]().
+				WithSize(3).
+				WithPartialFlusher(buffer.TypeRoutingFlusher(map[reflect.Type]buffer.Flusher[any]{
+					reflect.TypeFor[int](): buffer.FlusherFunc[any](func(items []any) error {
+						ints = append(ints, items)
+						return nil
+					}),
+					reflect.TypeFor[string](): buffer.FlusherFunc[any](func(items []any) error {
+						strings = append(strings, items)
+						if attempts++; attempts == 1 {
+							return errors.New("sink is down")
+						}
+						return nil
+					}),
+				}, nil)).
+				WithRetries(2, time.Millisecond)
+
+			// act
+			err := sut.Push(1)
+			_ = sut.Push("a")
+			_ = sut.Push(2)
+			err1 := sut.Close()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(ints).To(Equal([][]any{{1, 2}}))
+			Expect(strings).To(Equal([][]any{{"a"}, {"a"}}))
+		})
+	})
+})
@@ -0,0 +1,99 @@
+package buffer
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// Health represents a snapshot of the buffer's health.
+	Health struct {
+		// Healthy reports whether the buffer as a whole is considered healthy.
+		Healthy bool
+		// Running reports whether the consume goroutine is running, meaning the
+		// buffer has been initialized and not closed.
+		Running bool
+		// LastFlush is when the last flush completed, successful or not.
+		LastFlush time.Time
+		// LastError is the error of the last flush, if it failed.
+		LastError error
+		// ConsecutiveFailures is the number of flushes that failed in a row.
+		ConsecutiveFailures uint
+	}
+
+	health struct {
+		mu          sync.Mutex
+		lastFlush   time.Time
+		lastErr     error
+		failures    uint
+		lastFailure time.Time
+	}
+)
+
+// Healthy reports whether the consume goroutine is running and flushes are not
+// failing, see HealthStatus.
+func (buffer *Buffer[T]) Healthy() bool {
+	return buffer.HealthStatus().Healthy
+}
+
+// HealthStatus returns a snapshot of the buffer's health for use in readiness
+// probes. A buffer is unhealthy when it has not been started, when it has been
+// closed, or when the last flushes all failed, see WithHealthThreshold. A buffer
+// with lazy initialization only becomes healthy after the first Push, unless it
+// is started with Start.
+func (buffer *Buffer[T]) HealthStatus() Health {
+	buffer.health.mu.Lock()
+	defer buffer.health.mu.Unlock()
+
+	status := Health{
+		Running:             buffer.IsIntialized() && !buffer.closed(),
+		LastFlush:           buffer.health.lastFlush,
+		LastError:           buffer.health.lastErr,
+		ConsecutiveFailures: buffer.health.failures,
+	}
+
+	threshold := max(buffer.HealthFailures, 1)
+	failing := status.ConsecutiveFailures >= threshold
+	if failing && buffer.HealthWindow > 0 {
+		failing = time.Since(buffer.health.lastFailure) <= buffer.HealthWindow
+	}
+	status.Healthy = status.Running && !failing
+
+	return status
+}
+
+// LastFlushTime returns when the last flush completed, successful or not, or the
+// zero time if the buffer has not flushed yet.
+func (buffer *Buffer[T]) LastFlushTime() time.Time {
+	buffer.health.mu.Lock()
+	defer buffer.health.mu.Unlock()
+
+	return buffer.health.lastFlush
+}
+
+// TimeSinceLastFlush returns how long ago the last flush completed, or zero if
+// the buffer has not flushed yet. A growing duration while items are pending
+// hints at a wedged buffer, see WithWatchdog.
+func (buffer *Buffer[T]) TimeSinceLastFlush() time.Duration {
+	last := buffer.LastFlushTime()
+	if last.IsZero() {
+		return 0
+	}
+
+	return time.Since(last)
+}
+
+// record is called after every flush with its outcome.
+func (h *health) record(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastFlush, h.lastErr = time.Now(), err
+	if err == nil {
+		h.failures = 0
+		return
+	}
+
+	h.failures++
+	h.lastFailure = h.lastFlush
+}
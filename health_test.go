@@ -0,0 +1,123 @@
+package buffer_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/omniboost/go-buffer"
+)
+
+var _ = Describe("Health", func() {
+	var flusher *MockFlusher[any]
+
+	BeforeEach(func() {
+		flusher = NewMockFlusher[any]()
+	})
+
+	It("reports a running buffer as healthy", func() {
+		// arrange
+		sut := buffer.New[any]().
+			WithSize(2).
+			WithFlusher(flusher)
+
+		// act
+		err := sut.Start()
+		status := sut.HealthStatus()
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(status.Healthy).To(BeTrue())
+		Expect(status.Running).To(BeTrue())
+		_ = sut.Close()
+	})
+
+	It("reports a buffer that was not started or is closed as unhealthy", func() {
+		// arrange
+		sut := buffer.New[any]().
+			WithSize(2).
+			WithFlusher(flusher)
+
+		healthy := sut.Healthy()
+		_ = sut.Start()
+		_ = sut.Close()
+
+		// act
+		healthy1 := sut.Healthy()
+
+		// assert
+		Expect(healthy).To(BeFalse())
+		Expect(healthy1).To(BeFalse())
+	})
+
+	It("reports unhealthy once the threshold of failed flushes is reached", func() {
+		// arrange
+		flusher.Err = errors.New("sink is down")
+		sut := buffer.New[any]().
+			WithSize(1).
+			WithFlusher(flusher).
+			WithHealthThreshold(2, 0)
+
+		// act
+		err := sut.Push(1)
+		<-flusher.Done
+		Eventually(func() uint { return sut.HealthStatus().ConsecutiveFailures }).Should(BeEquivalentTo(1))
+		healthy := sut.Healthy()
+		_ = sut.Push(2)
+		<-flusher.Done
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(healthy).To(BeTrue())
+		Eventually(sut.Healthy).Should(BeFalse())
+		Expect(sut.HealthStatus().LastError).To(MatchError("sink is down"))
+		_ = sut.Close()
+	})
+
+	It("recovers once failures fall outside of the window", func() {
+		// arrange
+		flusher.Err = errors.New("sink is down")
+		sut := buffer.New[any]().
+			WithSize(1).
+			WithFlusher(flusher).
+			WithHealthThreshold(1, 50*time.Millisecond)
+
+		// act
+		err := sut.Push(1)
+		<-flusher.Done
+
+		// assert
+		Expect(err).To(Succeed())
+		Eventually(sut.Healthy).Should(BeFalse())
+		Eventually(sut.Healthy).Should(BeTrue())
+		_ = sut.Close()
+	})
+
+	It("reports when the last flush completed", func() {
+		// arrange
+		sut := buffer.New[any]().
+			WithSize(2).
+			WithFlusher(flusher)
+
+		err := sut.Start()
+		last := sut.LastFlushTime()
+		since := sut.TimeSinceLastFlush()
+		before := time.Now()
+
+		// act
+		err1 := sut.Push(1)
+		_ = sut.Push(2)
+		<-flusher.Done
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(err1).To(Succeed())
+		Expect(last).To(BeZero())
+		Expect(since).To(BeZero())
+		Eventually(sut.LastFlushTime).Should(BeTemporally(">=", before))
+		Expect(sut.TimeSinceLastFlush()).To(BeNumerically("<", time.Second))
+		_ = sut.Close()
+	})
+})
@@ -0,0 +1,31 @@
+package buffer
+
+import "time"
+
+type (
+	// Metrics represents a sink for the buffer's instrumentation.
+	//
+	// Implementations must be safe for concurrent use, as pushes and flushes
+	// report from different goroutines.
+	Metrics interface {
+		// IncPushed counts items accepted by the buffer.
+		IncPushed(n int)
+		// IncFlushed counts batches and items handed to the flusher.
+		IncFlushed(batches, items int)
+		// IncDropped counts items that could not be pushed, or that were dropped
+		// from the buffer by eviction or sampling.
+		IncDropped(n int)
+		// ObserveFlushDuration records how long writing a batch took.
+		ObserveFlushDuration(d time.Duration)
+		// IncErrors counts batches that could not be written.
+		IncErrors(n int)
+	}
+
+	noopMetrics struct{}
+)
+
+func (noopMetrics) IncPushed(int)                      {}
+func (noopMetrics) IncFlushed(int, int)                {}
+func (noopMetrics) IncDropped(int)                     {}
+func (noopMetrics) ObserveFlushDuration(time.Duration) {}
+func (noopMetrics) IncErrors(int)                      {}
@@ -0,0 +1,138 @@
+package buffer_test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/omniboost/go-buffer"
+)
+
+var _ = Describe("Metrics", func() {
+	var (
+		flusher *MockFlusher[any]
+		metrics *MockMetrics
+	)
+
+	BeforeEach(func() {
+		flusher = NewMockFlusher[any]()
+		metrics = &MockMetrics{}
+	})
+
+	It("reports pushes and flushes", func() {
+		// arrange
+		sut := buffer.New[any]().
+			WithSize(2).
+			WithFlusher(flusher).
+			WithMetrics(metrics)
+
+		// act
+		err := sut.Push(1)
+		_ = sut.Push(2)
+		err1 := sut.Close()
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(err1).To(Succeed())
+		Expect(metrics.Snapshot()).To(Equal(MockMetrics{
+			Pushed:    2,
+			Batches:   1,
+			Items:     2,
+			Durations: 1,
+		}))
+	})
+
+	It("reports errors", func() {
+		// arrange
+		flusher.Err = errors.New("sink is down")
+		sut := buffer.New[any]().
+			WithSize(1).
+			WithFlusher(flusher).
+			WithMetrics(metrics)
+
+		// act
+		err := sut.Push(1)
+		err1 := sut.Close()
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(err1).To(Succeed())
+		Expect(metrics.Snapshot().Errors).To(Equal(1))
+	})
+
+	It("reports dropped items", func() {
+		// arrange
+		flusher.Func = func() { time.Sleep(100 * time.Millisecond) }
+		sut := buffer.New[any]().
+			WithSize(1).
+			WithFlusher(flusher).
+			WithPushTimeout(10 * time.Millisecond).
+			WithMetrics(metrics)
+
+		// act
+		err := sut.Push(1)
+		err1 := sut.Push(2)
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(err1).To(MatchError(buffer.ErrTimeout))
+		Expect(metrics.Snapshot().Dropped).To(Equal(1))
+	})
+})
+
+type MockMetrics struct {
+	mu        sync.Mutex
+	Pushed    int
+	Batches   int
+	Items     int
+	Dropped   int
+	Durations int
+	Errors    int
+}
+
+func (m *MockMetrics) IncPushed(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Pushed += n
+}
+
+func (m *MockMetrics) IncFlushed(batches, items int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Batches += batches
+	m.Items += items
+}
+
+func (m *MockMetrics) IncDropped(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Dropped += n
+}
+
+func (m *MockMetrics) ObserveFlushDuration(time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Durations++
+}
+
+func (m *MockMetrics) IncErrors(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Errors += n
+}
+
+func (m *MockMetrics) Snapshot() MockMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return MockMetrics{
+		Pushed:    m.Pushed,
+		Batches:   m.Batches,
+		Items:     m.Items,
+		Dropped:   m.Dropped,
+		Durations: m.Durations,
+		Errors:    m.Errors,
+	}
+}
@@ -0,0 +1,82 @@
+package buffer
+
+import "time"
+
+type (
+	// FlusherMiddleware represents a decorator that wraps a flusher with
+	// additional behavior.
+	FlusherMiddleware[T any] func(next Flusher[T]) Flusher[T]
+)
+
+// Chain wraps base with the provided middleware. The first middleware is the
+// outermost one, so it is the first to see every batch.
+func Chain[T any](base Flusher[T], mw ...FlusherMiddleware[T]) Flusher[T] {
+	flusher := base
+	for i := len(mw) - 1; i >= 0; i-- {
+		flusher = mw[i](flusher)
+	}
+
+	return flusher
+}
+
+// WithFilterMW creates a middleware that only passes on the items for which keep
+// returns true. A batch that ends up empty is not passed on at all.
+func WithFilterMW[T any](keep func(item T) bool) FlusherMiddleware[T] {
+	return func(next Flusher[T]) Flusher[T] {
+		return FlusherFunc[T](func(items []T) error {
+			kept := make([]T, 0, len(items))
+			for _, item := range items {
+				if keep(item) {
+					kept = append(kept, item)
+				}
+			}
+			if len(kept) == 0 {
+				return nil
+			}
+
+			return next.Write(kept)
+		})
+	}
+}
+
+// WithRetryMW creates a middleware that retries a failed write like WithRetries
+// does, with a backoff that starts at the given duration and doubles after
+// every attempt.
+func WithRetryMW[T any](retries uint, backoff time.Duration) FlusherMiddleware[T] {
+	return func(next Flusher[T]) Flusher[T] {
+		return FlusherFunc[T](func(items []T) error {
+			return retry(next, items, retries, retryBackoff{delay: backoff})
+		})
+	}
+}
+
+// WithMetricsMW creates a middleware that reports every write to metrics, the
+// same way WithMetrics reports the flushes of a buffer.
+func WithMetricsMW[T any](metrics Metrics) FlusherMiddleware[T] {
+	return func(next Flusher[T]) Flusher[T] {
+		return FlusherFunc[T](func(items []T) error {
+			start := time.Now()
+			err := next.Write(items)
+			metrics.ObserveFlushDuration(time.Since(start))
+			metrics.IncFlushed(1, len(items))
+			if err != nil {
+				metrics.IncErrors(1)
+			}
+
+			return err
+		})
+	}
+}
+
+// MeteredFlusher creates a flusher that times every write to next, and reports
+// its duration, the size of the batch and the resulting error to observe, for
+// instance to feed a latency histogram.
+func MeteredFlusher[T any](next Flusher[T], observe func(d time.Duration, items int, err error)) Flusher[T] {
+	return FlusherFunc[T](func(items []T) error {
+		start := time.Now()
+		err := next.Write(items)
+		observe(time.Since(start), len(items), err)
+
+		return err
+	})
+}
@@ -0,0 +1,116 @@
+package buffer_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/omniboost/go-buffer"
+)
+
+var _ = Describe("Middleware", func() {
+	var flusher *MockFlusher[int]
+
+	BeforeEach(func() {
+		flusher = NewMockFlusher[int]()
+	})
+
+	It("applies middleware from the outermost to the innermost", func() {
+		// arrange
+		var calls []string
+		trace := func(name string) buffer.FlusherMiddleware[int] {
+			return func(next buffer.Flusher[int]) buffer.Flusher[int] {
+				return buffer.FlusherFunc[int](func(items []int) error {
+					calls = append(calls, name)
+					return next.Write(items)
+				})
+			}
+		}
+		sut := buffer.Chain[int](flusher, trace("outer"), trace("inner"))
+
+		// act
+		err := sut.Write([]int{1})
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(calls).To(Equal([]string{"outer", "inner"}))
+		Expect(flusher.Done).To(Receive())
+	})
+
+	It("filters items", func() {
+		// arrange
+		sut := buffer.Chain[int](flusher, buffer.WithFilterMW(func(item int) bool { return item%2 == 0 }))
+
+		// act
+		err := sut.Write([]int{1, 2, 3, 4})
+
+		// assert
+		Expect(err).To(Succeed())
+		var result *WriteCall[int]
+		Expect(flusher.Done).To(Receive(&result))
+		Expect(result.Items).To(Equal([]int{2, 4}))
+	})
+
+	It("retries failed writes", func() {
+		// arrange
+		attempts := 0
+		sut := buffer.Chain[int](
+			buffer.FlusherFunc[int](func(items []int) error {
+				attempts++
+				return errors.New("sink is down")
+			}),
+			buffer.WithRetryMW[int](2, time.Millisecond),
+		)
+
+		// act
+		err := sut.Write([]int{1})
+
+		// assert
+		Expect(err).To(MatchError("sink is down"))
+		Expect(attempts).To(Equal(3))
+	})
+
+	It("reports writes to metrics", func() {
+		// arrange
+		metrics := &MockMetrics{}
+		flusher.Err = errors.New("sink is down")
+		sut := buffer.Chain[int](flusher, buffer.WithMetricsMW[int](metrics))
+
+		// act
+		err := sut.Write([]int{1, 2})
+
+		// assert
+		Expect(err).To(HaveOccurred())
+		Expect(metrics.Snapshot()).To(Equal(MockMetrics{
+			Batches:   1,
+			Items:     2,
+			Durations: 1,
+			Errors:    1,
+		}))
+	})
+
+	It("times every write of a metered flusher", func() {
+		// arrange
+		var (
+			duration time.Duration
+			size     int
+			failure  error
+		)
+		flusher.Err = errors.New("sink is down")
+		flusher.Func = func() { time.Sleep(10 * time.Millisecond) }
+		sut := buffer.MeteredFlusher[int](flusher, func(d time.Duration, items int, err error) {
+			duration, size, failure = d, items, err
+		})
+
+		// act
+		err := sut.Write([]int{1, 2})
+
+		// assert
+		Expect(err).To(MatchError("sink is down"))
+		Expect(duration).To(BeNumerically(">=", 10*time.Millisecond))
+		Expect(size).To(Equal(2))
+		Expect(failure).To(MatchError("sink is down"))
+	})
+})
@@ -3,14 +3,57 @@ package buffer
 import (
 	"errors"
 	"fmt"
+	"math/rand"
+	"reflect"
 	"time"
 )
 
-const (
-	ErrInvalidSize     = "size cannot be zero"
-	ErrInvalidFlusher  = "flusher cannot be nil"
-	ErrInvalidInterval = "interval must be greater than zero (%s)"
-	ErrInvalidTimeout  = "timeout cannot be negative (%s)"
+var (
+	// ErrInvalidSize indicates the buffer size is zero.
+	ErrInvalidSize = errors.New("size cannot be zero")
+	// ErrInvalidFlusher indicates the flusher is nil.
+	ErrInvalidFlusher = errors.New("flusher cannot be nil")
+	// ErrInvalidInterval indicates an interval is negative.
+	ErrInvalidInterval = errors.New("interval cannot be negative")
+	// ErrInvalidTimeout indicates a timeout is negative.
+	ErrInvalidTimeout = errors.New("timeout cannot be negative")
+	// ErrInvalidMemoryLimit indicates the memory limit is negative, or set
+	// without a way to measure items.
+	ErrInvalidMemoryLimit = errors.New("memory limit cannot be negative and requires a size function")
+	// ErrInvalidRequeueAttempts indicates the maximum number of requeue attempts is negative.
+	ErrInvalidRequeueAttempts = errors.New("requeue attempts cannot be negative")
+	// ErrInvalidSampleRate indicates the sample rate is outside of the (0, 1] range.
+	ErrInvalidSampleRate = errors.New("sample rate must be between 0 and 1")
+	// ErrInvalidMaxWriteBatch indicates the maximum write batch size is negative.
+	ErrInvalidMaxWriteBatch = errors.New("max write batch cannot be negative")
+	// ErrInvalidMaxInFlightBatches indicates the maximum number of batches in
+	// flight is negative.
+	ErrInvalidMaxInFlightBatches = errors.New("max in-flight batches cannot be negative")
+	// ErrInvalidFlushOnFull indicates size-triggered flushes are disabled without
+	// a flush interval to flush the buffer instead.
+	ErrInvalidFlushOnFull = errors.New("disabling flush on full requires a flush interval")
+	// ErrInvalidRetryJitter indicates the retry jitter factor is outside of the
+	// [0, 1] range.
+	ErrInvalidRetryJitter = errors.New("retry jitter must be between 0 and 1")
+	// ErrInvalidChannelBuffer indicates the channel buffer is negative, or holds
+	// fewer items than a batch.
+	ErrInvalidChannelBuffer = errors.New("channel buffer cannot be negative or smaller than size")
+	// ErrInvalidPushRetry indicates the number of push attempts is negative, or
+	// set without a positive backoff.
+	ErrInvalidPushRetry = errors.New("push retry attempts cannot be negative and require a positive backoff")
+	// ErrInvalidDistinctKeys indicates the number of distinct keys that triggers a
+	// flush is negative, or set without a key function.
+	ErrInvalidDistinctKeys = errors.New("distinct key trigger cannot be negative and requires a key function")
+	// ErrInvalidErrCh indicates the size of the error channel is negative.
+	ErrInvalidErrCh = errors.New("error channel size cannot be negative")
+	// ErrInvalidSynchronousMode indicates synchronous mode is combined with an
+	// option that requires flushing in the background.
+	ErrInvalidSynchronousMode = errors.New("synchronous mode does not support overlapping flushes, flush lanes, disabling flush on full and a channel buffer")
+	// ErrInvalidWatchdog indicates the watchdog period is negative, or set
+	// without a function to call when the buffer is stuck.
+	ErrInvalidWatchdog = errors.New("watchdog period cannot be negative and requires a stuck function")
+	// ErrInvalidMarks indicates the pressure water marks are out of range.
+	ErrInvalidMarks = errors.New("water marks must satisfy 0 < low <= high <= size")
 )
 
 type (
@@ -18,7 +61,9 @@ type (
 	Option[T any] func(*Buffer[T])
 )
 
-// WithSize sets the size of the buffer.
+// WithSize sets the size of the buffer. The buffer flushes as soon as it holds
+// exactly size items: the push that fills it triggers the flush, and its item
+// is part of the flushed batch.
 func (b *Buffer[T]) WithSize(size uint) *Buffer[T] {
 	b.Size = size
 	return b
@@ -30,49 +75,720 @@ func (b *Buffer[T]) WithFlusher(flusher Flusher[T]) *Buffer[T] {
 	return b
 }
 
+// WithContextFlusher sets a context-aware flusher that should be used to write
+// out the buffer instead of the regular flusher. It receives the context passed
+// to FlushContext, or the background context for any other flush.
+func (b *Buffer[T]) WithContextFlusher(flusher ContextFlusher[T]) *Buffer[T] {
+	b.ContextFlusher = flusher
+	return b
+}
+
+// WithFlusherSelector sets a function that picks the flusher for every batch at
+// flush time, based on its contents, instead of using a single fixed flusher. It
+// takes precedence over both the regular and the context-aware flusher. A batch
+// for which the selector returns nil is not written, and is failed with an
+// ErrNoFlusher instead.
+func (b *Buffer[T]) WithFlusherSelector(selector func(items []T) Flusher[T]) *Buffer[T] {
+	b.FlusherSelector = selector
+	return b
+}
+
+// WithTimestamps sets a flusher that receives every item along with the time it
+// was pushed, instead of the regular flusher, so the sink can measure how long
+// items spent in the buffer. It takes precedence over every other flusher.
+//
+// Items that were requeued keep their original push time. A batch transform
+// that changes the number of items discards the push times, leaving them zero.
+func (b *Buffer[T]) WithTimestamps(flusher TimestampedFlusher[T]) *Buffer[T] {
+	b.TimestampedFlusher = flusher
+	return b
+}
+
+// WithPartialFlusher sets a flusher that reports which items of a batch it could
+// not write, instead of the regular flusher, so that only those are retried,
+// requeued, dead-lettered or handed to the error handler. The other items are
+// acknowledged as written. It takes precedence over every other flusher but a
+// timestamped one.
+//
+// Every retry writes the items that failed the previous attempt, and every
+// requeued item counts its own attempts. Failed items are matched to the batch
+// with reflect.DeepEqual, and the whole batch is considered failed when one of
+// them cannot be matched, for instance after a batch transform. Failed items
+// returned without an error fail with an ErrPartialWrite, while an error
+// returned without failed items fails every item.
+func (b *Buffer[T]) WithPartialFlusher(flusher PartialFlusher[T]) *Buffer[T] {
+	b.PartialFlusher = flusher
+	return b
+}
+
 // WithFlushInterval sets the interval between automatic flushes.
+//
+// The interval is paused while the buffer is empty, and starts over with the
+// first item pushed afterwards, unless it is stable, see WithStableInterval, or
+// empty flushes are reported, see WithOnEmptyFlush.
 func (b *Buffer[T]) WithFlushInterval(interval time.Duration) *Buffer[T] {
 	b.FlushInterval = interval
 	return b
 }
 
+// WithFlushTrigger makes the buffer flush whenever ch receives a value, and once
+// when ch is closed, so a single close can flush many buffers at once without
+// calling Flush on each of them.
+func (b *Buffer[T]) WithFlushTrigger(ch <-chan struct{}) *Buffer[T] {
+	b.FlushTrigger = ch
+	return b
+}
+
+// WithStableInterval keeps the flush interval on its original schedule when
+// Flush or one of its variants is called. By default every flush restarts the
+// interval, so periodic manual flushes make the interval flushes drift. A stable
+// interval keeps ticking while the buffer is empty rather than pausing.
+func (b *Buffer[T]) WithStableInterval() *Buffer[T] {
+	b.StableInterval = true
+	return b
+}
+
+// WithFlushDebounce collapses calls to Flush made within d of each other into a
+// single flush, which runs once Flush has not been called for d. Flush still
+// returns as soon as its call has been accepted. The variants of Flush that take
+// a context, wait for the flush or only flush part of the buffer are never
+// debounced.
+func (b *Buffer[T]) WithFlushDebounce(d time.Duration) *Buffer[T] {
+	b.FlushDebounce = d
+	return b
+}
+
 // WithPushTimeout sets how long a push should wait before giving up.
 func (b *Buffer[T]) WithPushTimeout(timeout time.Duration) *Buffer[T] {
 	b.PushTimeout = timeout
 	return b
 }
 
+// WithPushRetry makes a push that cannot be handed off right away try again up
+// to attempts times, waiting backoff for the first attempt and twice as long for
+// every following one, instead of waiting for the push timeout. A push gives up
+// with an ErrClosed as soon as Close is called, and with a timeout error once
+// every attempt failed. Zero attempts disable retrying.
+func (b *Buffer[T]) WithPushRetry(attempts int, backoff time.Duration) *Buffer[T] {
+	b.PushRetryAttempts = attempts
+	b.PushRetryBackoff = backoff
+	return b
+}
+
 // WithFlushTimeout sets how long a manual flush should wait before giving up.
 func (b *Buffer[T]) WithFlushTimeout(timeout time.Duration) *Buffer[T] {
 	b.FlushTimeout = timeout
 	return b
 }
 
+// WithPerItemFlushTimeout sets how much the flush timeout grows for every
+// pending item, so that the effective timeout becomes FlushTimeout plus the
+// pending count times the given duration.
+func (b *Buffer[T]) WithPerItemFlushTimeout(timeout time.Duration) *Buffer[T] {
+	b.PerItemFlushTimeout = timeout
+	return b
+}
+
+// WithTimeoutError sets a function that builds the error returned when an
+// operation times out, so it can be mapped onto an application's own errors.
+// The op argument is one of "push", "flush", "close", "stats" and "snapshot".
+// The returned error is joined with the default description of the timeout and
+// ErrTimeout, so errors.Is keeps working.
+func (b *Buffer[T]) WithTimeoutError(fn func(op string) error) *Buffer[T] {
+	b.TimeoutError = fn
+	return b
+}
+
 // WithCloseTimeout sets how long
 func (b *Buffer[T]) WithCloseTimeout(timeout time.Duration) *Buffer[T] {
 	b.CloseTimeout = timeout
 	return b
 }
 
+// WithCopyOnFlush makes the buffer hand the flusher a freshly allocated copy of
+// each batch, so the flusher is free to retain it after Write returns.
+func (b *Buffer[T]) WithCopyOnFlush() *Buffer[T] {
+	b.CopyOnFlush = true
+	return b
+}
+
+// WithWaterMarks sets the thresholds used by PressureSignal: pressure is
+// signalled when the pending count reaches high and released once it drops
+// below low. High defaults to 80% of Size and low to half of high. A high mark
+// of Size is only signalled as long as the buffer is not flushed when full, as
+// the flush releases the pressure right away, see WithFlushOnFull.
+func (b *Buffer[T]) WithWaterMarks(high, low uint) *Buffer[T] {
+	b.HighWaterMark = high
+	b.LowWaterMark = low
+	return b
+}
+
+// WithBatchValidator sets a function that validates every assembled batch right
+// before it is written. A batch that fails validation is not written, and is
+// passed to the error handler instead.
+func (b *Buffer[T]) WithBatchValidator(validator func(items []T) error) *Buffer[T] {
+	b.BatchValidator = validator
+	return b
+}
+
+// WithBatchTransform sets a function that transforms every assembled batch right
+// before it is written, for instance to sort, dedupe or reorder it. The returned
+// slice is written instead, and returning an empty slice skips the write. The
+// function may modify the batch in place, and runs on the goroutine that writes
+// the batch, which is the consume goroutine unless flushes overlap.
+func (b *Buffer[T]) WithBatchTransform(fn func(items []T) []T) *Buffer[T] {
+	b.BatchTransform = fn
+	return b
+}
+
+// WithErrorHandler sets the function that is called with the error and the
+// affected batch whenever a batch could not be written, and could not be handed
+// to the dead-letter flusher either.
+func (b *Buffer[T]) WithErrorHandler(handler func(err error, items []T)) *Buffer[T] {
+	b.ErrorHandler = handler
+	return b
+}
+
+// WithPanicHandler recovers a panic of the flusher, handing the recovered value
+// and the stack trace to handler, so bugs can be told apart from the failures
+// reported to the error handler. The batch is then neither retried, requeued,
+// dead-lettered nor handed to the error handler, and its acks receive an
+// ErrFlusherPanicked.
+//
+// Without a panic handler a panic of the flusher is not recovered, so it
+// crashes the program like an unrecovered panic in any other goroutine.
+func (b *Buffer[T]) WithPanicHandler(handler func(recovered any, stack []byte)) *Buffer[T] {
+	b.PanicHandler = handler
+	return b
+}
+
+// WithRetries sets how many times a failed write is retried before the batch is
+// considered permanently failed. The backoff between retries starts at the
+// given duration and doubles after every attempt.
+func (b *Buffer[T]) WithRetries(retries uint, backoff time.Duration) *Buffer[T] {
+	b.Retries = retries
+	b.RetryBackoff = backoff
+	return b
+}
+
+// WithRetryJitter randomizes the backoff between retries to spread the retries
+// of many buffers over time: every delay is shortened by a random amount of up
+// to factor times itself. A factor of 1 is full jitter, and 0.5 equal jitter.
+func (b *Buffer[T]) WithRetryJitter(factor float64) *Buffer[T] {
+	b.RetryJitter = factor
+	return b
+}
+
+// WithRetryMaxElapsed gives up retrying a write once the retries would take
+// longer than d in total, even if not all of them were attempted, which bounds
+// how long a single batch can keep the buffer busy.
+func (b *Buffer[T]) WithRetryMaxElapsed(d time.Duration) *Buffer[T] {
+	b.RetryMaxElapsed = d
+	return b
+}
+
+// WithRetryRandSource sets the source of randomness used for retry jitter,
+// which makes it deterministic when seeded with a fixed value. It is not copied
+// by Clone, so it must not be shared with other buffers. It defaults to the
+// global source of the math/rand package.
+func (b *Buffer[T]) WithRetryRandSource(src rand.Source) *Buffer[T] {
+	b.RetryRandSource = src
+	return b
+}
+
+// WithRequeueOnError makes a failed batch go back to the front of the buffer,
+// ahead of any newer items, to be retried with the next batch instead of being
+// retried inline. Every item is attempted at most maxAttempts times, after which
+// it is handed to the dead-letter flusher or the error handler. Requeued items
+// that no longer fit in the buffer, and items that fail during the final flush
+// on Close, are failed right away.
+//
+// Requeueing keeps the consume goroutine responsive, at the cost of the push
+// order: a requeued item is written after items that were flushed in the
+// meantime, and with overlapping flushes after items pushed while it was being
+// written. Retries configured with WithRetries still happen inline first.
+func (b *Buffer[T]) WithRequeueOnError(maxAttempts int) *Buffer[T] {
+	b.RequeueAttempts = maxAttempts
+	return b
+}
+
+// WithDeadLetter sets the flusher that receives batches which could not be
+// written once all retries have been exhausted.
+func (b *Buffer[T]) WithDeadLetter(flusher Flusher[T]) *Buffer[T] {
+	b.DeadLetter = flusher
+	return b
+}
+
+// WithOverlappingFlush makes every flush run on its own goroutine, so the buffer
+// keeps accepting items and a new flush can start while a previous one is still
+// being written.
+//
+// By default a flush blocks the buffer, and the flush interval only starts
+// counting again once the flush has finished: with a flusher slower than the
+// interval, flushes happen back to back rather than on schedule. With
+// overlapping flushes the interval restarts as soon as a batch has been handed
+// off, at the cost of batches possibly being written concurrently and out of
+// order. The flusher must therefore be safe for concurrent use.
+func (b *Buffer[T]) WithOverlappingFlush() *Buffer[T] {
+	b.OverlappingFlush = true
+	return b
+}
+
+// WithExclusiveFlush serializes every write to the flusher, regardless of
+// WithOverlappingFlush, WithOrderedConcurrency or any other concurrency option,
+// so a flusher that is not safe for concurrent use can still be combined with
+// them. Batches are still assembled and handed off concurrently, and retries of
+// a batch hold off the writes of other batches.
+func (b *Buffer[T]) WithExclusiveFlush() *Buffer[T] {
+	b.ExclusiveFlush = true
+	return b
+}
+
+// WithMaxInFlightBatches bounds the number of batches overlapping flushes write
+// at the same time, see WithOverlappingFlush and WithOrderedConcurrency. Once n
+// batches are in flight, the buffer waits for one of them to complete before
+// starting another, which in turn blocks pushes once the buffer is full. Zero
+// means no limit.
+func (b *Buffer[T]) WithMaxInFlightBatches(n int) *Buffer[T] {
+	b.MaxInFlightBatches = n
+	return b
+}
+
+// WithFlushOrder sets the order of the items within every batch handed to the
+// flusher, which defaults to FIFO. The order applies per batch only: batches are
+// still flushed one after the other, oldest first, so LIFO does not make the
+// most recent items overtake older batches.
+func (b *Buffer[T]) WithFlushOrder(order FlushOrder) *Buffer[T] {
+	b.FlushOrder = order
+	return b
+}
+
+// WithClosedPushBehavior sets what happens to an item pushed to a closed buffer.
+// It defaults to ReturnError, while SilentlyDrop discards the item without an
+// error, which avoids log noise from producers that keep pushing briefly while
+// shutting down.
+func (b *Buffer[T]) WithClosedPushBehavior(behavior ClosedPushBehavior) *Buffer[T] {
+	b.ClosedPushBehavior = behavior
+	return b
+}
+
+// WithFlusherClose closes the flusher once the buffer is closed, after the final
+// flush, when it implements io.Closer. Close returns the error of closing the
+// flusher, if any. The dead-letter flusher is left open.
+func (b *Buffer[T]) WithFlusherClose() *Buffer[T] {
+	b.CloseFlusher = true
+	return b
+}
+
+// WithOnClose sets a function that is called exactly once when the buffer
+// closes, after the final flush has completed and before Close returns. No
+// flush runs after it.
+func (b *Buffer[T]) WithOnClose(fn func()) *Buffer[T] {
+	b.OnClose = fn
+	return b
+}
+
+// WithMetrics sets the sink that receives the buffer's instrumentation. When no
+// metrics are set, a no-op implementation is used.
+func (b *Buffer[T]) WithMetrics(metrics Metrics) *Buffer[T] {
+	b.Metrics = metrics
+	return b
+}
+
+// WithItemValidator makes Push run validate on every item, rejecting the items
+// it fails for with an ErrInvalidItem joined with its error instead of
+// buffering them. It runs on the pushing goroutine, after the zero value check
+// of WithRejectZeroValue.
+func (b *Buffer[T]) WithItemValidator(validate func(item T) error) *Buffer[T] {
+	b.ItemValidator = validate
+	return b
+}
+
+// WithRejectZeroValue makes Push reject zero values, such as nil pointers, with
+// an ErrZeroValue instead of buffering them. Items are checked with isZero, or
+// through reflection when isZero is nil.
+func (b *Buffer[T]) WithRejectZeroValue(isZero func(item T) bool) *Buffer[T] {
+	if isZero == nil {
+		isZero = func(item T) bool {
+			return reflect.ValueOf(&item).Elem().IsZero()
+		}
+	}
+
+	b.IsZero = isZero
+	return b
+}
+
+// WithOrderedConcurrency makes flushes overlap like WithOverlappingFlush, while
+// batches that share the key derived by keyFn are still written one after the
+// other, in push order. Batches with different keys are written in parallel.
+func WithOrderedConcurrency[T any, K comparable](keyFn func(items []T) K) Option[T] {
+	return func(b *Buffer[T]) {
+		b.OverlappingFlush = true
+		b.FlushLaneKey = func(items []T) any { return keyFn(items) }
+	}
+}
+
+// WithStrictSize guarantees that the flusher is never handed more than Size
+// items at once, for sinks with a hard limit per request. A batch only grows
+// past Size through WithBatchTransform, and is then written in chunks like
+// with WithMaxWriteBatch, which takes precedence when it is smaller. The
+// dead-letter flusher is handed failed items as they are.
+func (b *Buffer[T]) WithStrictSize() *Buffer[T] {
+	b.StrictSize = true
+	return b
+}
+
+// WithFlushGroupBy partitions every flushed batch by the key derived by keyFn,
+// and writes each group with a separate call to the flusher, so that every call
+// receives a homogeneous batch. The flusher is therefore called multiple times
+// per flush, once per group, in the order in which the groups first appear in
+// the batch. The order of the items within a group is preserved.
+//
+// A group that cannot be written is failed on its own, while the items of the
+// groups that were written are acknowledged as written. Combined with
+// WithRequeueOnError, only the items of the failed groups are requeued.
+func WithFlushGroupBy[T any, K comparable](keyFn func(item T) K) Option[T] {
+	return func(b *Buffer[T]) {
+		b.FlushGroupKey = func(item T) any { return keyFn(item) }
+	}
+}
+
+// WithMaxWriteBatch caps the number of items handed to a single call to the
+// flusher, independently of the buffer size. A larger batch is written in
+// chunks of at most n items, in push order. The first chunk that cannot be
+// written stops the flush, and is failed along with the chunks after it, while
+// the items of the chunks already written are acknowledged as written. Combined
+// with WithRequeueOnError, only the failed items are requeued. Failed items are
+// matched to the batch with reflect.DeepEqual, so the whole batch is failed
+// when a batch transform changed its items.
+func (b *Buffer[T]) WithMaxWriteBatch(n int) *Buffer[T] {
+	b.MaxWriteBatch = n
+	return b
+}
+
+// WithDedupWindow drops items at flush time whose key, as derived by keyFn, was
+// already flushed within the given window, so retries upstream do not result in
+// duplicate writes downstream. A key is remembered from the flush it was first
+// part of, whether or not that flush succeeded, and at most 65536 keys are
+// remembered at a time. Items requeued by WithRequeueOnError are never dropped.
+// Dropped items are counted in Stats.
+func WithDedupWindow[T any, K comparable](keyFn func(item T) K, window time.Duration) Option[T] {
+	return func(b *Buffer[T]) {
+		b.DedupKey = func(item T) any { return keyFn(item) }
+		b.DedupWindow = window
+	}
+}
+
+// WithLastValuePerKey keeps only the latest item per key, as derived by keyFn:
+// pushing an item whose key is already buffered overwrites the buffered item in
+// place, so the buffer holds up to Size distinct keys and is flushed once it is
+// full of them. Flushed batches keep the order in which keys were first pushed.
+// Superseded items are acknowledged as written and counted in Stats. Unlike
+// WithDedupWindow, it only coalesces items that are still buffered. The memory
+// limit is only enforced when a new key is pushed.
+func WithLastValuePerKey[T any, K comparable](keyFn func(item T) K) Option[T] {
+	return func(b *Buffer[T]) {
+		b.LastValueKey = func(item T) any { return keyFn(item) }
+	}
+}
+
+// WithDistinctKeyTrigger flushes the buffer once it holds items for n distinct
+// keys, as derived by keyFn, rather than only once it holds Size items, which
+// suits workloads that batch one update per entity. The buffer keeps a set of
+// the keys of its items, holding up to Size keys, which is rebuilt from the
+// buffered items whenever items are flushed or dropped, so keyFn must be cheap.
+// An n larger than Size never triggers a flush.
+func WithDistinctKeyTrigger[T any, K comparable](keyFn func(item T) K, n int) Option[T] {
+	return func(b *Buffer[T]) {
+		b.DistinctKey = func(item T) any { return keyFn(item) }
+		b.DistinctKeys = n
+	}
+}
+
+// WithFlushOnFull sets whether the buffer is flushed as soon as it is full, which
+// is the default. When disabled, a full buffer blocks pushes until the next
+// interval or manual flush instead, so the buffer is flushed at a strictly
+// periodic cadence. Priority pushes block until then as well. Disabling it
+// requires a flush interval.
+func (b *Buffer[T]) WithFlushOnFull(enabled bool) *Buffer[T] {
+	b.NoFlushOnFull = !enabled
+	return b
+}
+
+// WithFlushOnClose sets whether the remaining items are flushed when the buffer
+// is closed, which is the default. When disabled, Close returns as soon as the
+// consume goroutine has exited and the remaining items are available through
+// Discarded instead.
+func (b *Buffer[T]) WithFlushOnClose(enabled bool) *Buffer[T] {
+	b.DiscardOnClose = !enabled
+	return b
+}
+
+// WithFlushDoneSignal enables the channel returned by FlushDone.
+func (b *Buffer[T]) WithFlushDoneSignal() *Buffer[T] {
+	b.FlushDoneSignal = true
+	return b
+}
+
+// WithMemoryLimit caps the total size of the buffered items, as measured by
+// sizeOf, at the given number of bytes. When a push would exceed the limit, the
+// buffer makes room according to its memory policy, calling onEvict for every
+// evicted item. An item that exceeds the limit on its own is still accepted.
+func (b *Buffer[T]) WithMemoryLimit(bytes int, sizeOf func(item T) int, onEvict func(item T)) *Buffer[T] {
+	b.MemoryLimit = bytes
+	b.SizeOf = sizeOf
+	b.OnEvict = onEvict
+	return b
+}
+
+// WithMemoryPolicy sets how the buffer makes room when a push would exceed the
+// memory limit. It defaults to MemoryFlush.
+func (b *Buffer[T]) WithMemoryPolicy(policy MemoryPolicy) *Buffer[T] {
+	b.MemoryPolicy = policy
+	return b
+}
+
+// WithInitialDelay suppresses all flushing, whether by interval, size or a
+// manual flush, for the given duration after the buffer is initialized. Items
+// are queued up to the buffer's capacity in the meantime, after which pushes
+// block. Once the delay has elapsed, the accumulated batch and any flush that
+// was requested in the meantime are flushed. Closing the buffer ends the delay
+// early.
+func (b *Buffer[T]) WithInitialDelay(delay time.Duration) *Buffer[T] {
+	b.InitialDelay = delay
+	return b
+}
+
+// WithExplicitStart makes Push fail with an ErrNotStarted until Start has been
+// called, instead of lazily initializing the buffer on the first Push. This
+// surfaces invalid options at startup rather than when the first item arrives.
+func (b *Buffer[T]) WithExplicitStart() *Buffer[T] {
+	b.RequireStart = true
+	return b
+}
+
+// WithSampleRate makes the buffer keep every item of a batch independently with
+// the given probability at flush time, in the (0, 1] range, and intentionally
+// drop the others before the batch reaches the flusher. Dropped items are
+// counted in Stats. A batch whose items are all dropped is not flushed at all.
+func (b *Buffer[T]) WithSampleRate(rate float64) *Buffer[T] {
+	b.SampleRate = rate
+	return b
+}
+
+// WithRandSource sets the source of randomness used for sampling, which makes
+// sampling deterministic when seeded with a fixed value. The source is only
+// used by the buffer's consume goroutine, and is not copied by Clone, so it must
+// not be shared with other buffers. It defaults to a source seeded with the
+// current time.
+func (b *Buffer[T]) WithRandSource(src rand.Source) *Buffer[T] {
+	b.RandSource = src
+	return b
+}
+
+// WithHealthThreshold sets how many flushes in a row have to fail before the
+// buffer reports itself unhealthy, which defaults to a single one. When window
+// is positive, failures older than window no longer make the buffer unhealthy.
+func (b *Buffer[T]) WithHealthThreshold(failures uint, window time.Duration) *Buffer[T] {
+	b.HealthFailures = failures
+	b.HealthWindow = window
+	return b
+}
+
+// WithWatchdog calls onStuck when no flush has completed for d while the buffer
+// holds items, which points at a flusher that blocks and wedges the consume
+// goroutine. It is called again every d for as long as the buffer stays stuck,
+// from a goroutine of its own.
+func (b *Buffer[T]) WithWatchdog(d time.Duration, onStuck func()) *Buffer[T] {
+	b.Watchdog = d
+	b.OnStuck = onStuck
+	return b
+}
+
+// WithChannelBuffer sets the depth of the intake queue in front of the buffer,
+// which defaults to zero. Size remains the target size of flushed batches: the
+// queue only lets pushes return right away while the consume goroutine is busy
+// flushing, rather than once it picks up their items.
+//
+//	Push ──▶ intake queue (n items) ──▶ buffer (Size items) ──▶ Flusher
+//
+// Items waiting in the queue are not counted by Len and Stats, and the queue
+// is emptied into the final flush on close; pushes are refused as soon as Close
+// is called. The queue must hold at least Size items, so that it can take in a
+// whole batch while the previous one is written, and has no use in synchronous
+// mode, which rejects it. A queue larger than Size holds more than one batch
+// worth of items per flush, so every item can take up to n/Size+1 flushes to be
+// written.
+func (b *Buffer[T]) WithChannelBuffer(n int) *Buffer[T] {
+	b.ChannelBuffer = n
+	return b
+}
+
+// WithErrCh makes the buffer publish the error of every failed flush on the
+// channel returned by Errors, which holds up to size errors. It complements the
+// error handler rather than replacing it.
+func (b *Buffer[T]) WithErrCh(size int) *Buffer[T] {
+	b.ErrChSize = size
+	return b
+}
+
+// WithSynchronousMode makes the buffer run without a consume goroutine, for
+// tests and benchmarks only: Push, Flush and Close process items and flush them
+// on the calling goroutine, one call at a time, so batching is deterministic.
+// There are no timers either, so the flush interval, the initial delay, the
+// flush debounce, the watchdog and every timeout are ignored, as is the flush
+// trigger. It cannot be combined with overlapping flushes, flush lanes,
+// disabling flush on full, or a channel buffer. It is not meant for production
+// use.
+func (b *Buffer[T]) WithSynchronousMode() *Buffer[T] {
+	b.Synchronous = true
+	return b
+}
+
+// WithOnEmptyFlush sets a function that is called with what triggered a flush
+// whenever the flush has nothing to write, for instance to tell how often
+// flushes are wasted. The flush interval keeps ticking while the buffer is empty
+// when it is set, so that it is called for every empty interval flush. It runs
+// on the consume goroutine.
+func (b *Buffer[T]) WithOnEmptyFlush(fn func(reason FlushReason)) *Buffer[T] {
+	b.OnEmptyFlush = fn
+	return b
+}
+
+// WithSkipCancelled drops the items pushed with PushWithAckContext whose context
+// is done by the time they are flushed rather than writing them, and calls their
+// ack with the context's error. Dropped items are counted in Stats.
+func (b *Buffer[T]) WithSkipCancelled() *Buffer[T] {
+	b.SkipCancelled = true
+	return b
+}
+
+// WithAfterFlush sets a function that is called after every flush that wrote
+// its whole batch, with the number of items written, making it a safe point to
+// commit offsets or checkpoints. It is not called for failed or empty flushes.
+// It runs synchronously on the goroutine performing the flush, before the
+// items' acks are called, and concurrently with other flushes when they
+// overlap.
+func (b *Buffer[T]) WithAfterFlush(fn func(count int)) *Buffer[T] {
+	b.AfterFlush = fn
+	return b
+}
+
+// WithSizeSchedule sets a function returning the flush threshold for the given
+// time, so that the buffer can flush smaller batches at some times of day than
+// at others. The threshold is capped at Size, which still sizes the buffer, and
+// a threshold of zero means Size. The consume goroutine consults the schedule
+// whenever an item is pushed and on every flush interval tick; a threshold that
+// drops to or below the number of buffered items flushes them right away.
+func (b *Buffer[T]) WithSizeSchedule(fn func(now time.Time) uint) *Buffer[T] {
+	b.SizeSchedule = fn
+	return b
+}
+
+// WithClock sets the function the size schedule is consulted with, defaulting
+// to time.Now. It is meant for tests.
+func (b *Buffer[T]) WithClock(now func() time.Time) *Buffer[T] {
+	b.Clock = now
+	return b
+}
+
+// WithPushHook sets a function that is called once every push resolves, with
+// the pushed item and whether the buffer accepted it. It is not accepted when
+// the push times out, fails, or is silently dropped because the buffer is
+// closed. The hook runs on the pushing goroutine, so a slow hook slows pushes.
+func (b *Buffer[T]) WithPushHook(fn func(item T, accepted bool)) *Buffer[T] {
+	b.PushHook = fn
+	return b
+}
+
 func validateBuffer[T any](options *Buffer[T]) error {
 	if options.Size == 0 {
-		return errors.New(ErrInvalidSize)
+		return ErrInvalidSize
 	}
-	if options.Flusher == nil {
-		return errors.New(ErrInvalidFlusher)
+	if options.Flusher == nil && options.ContextFlusher == nil && options.FlusherSelector == nil &&
+		options.TimestampedFlusher == nil && options.PartialFlusher == nil {
+		return ErrInvalidFlusher
 	}
 	if options.FlushInterval < 0 {
-		return fmt.Errorf(ErrInvalidInterval, "FlushInterval")
+		return invalidField(ErrInvalidInterval, "FlushInterval")
+	}
+	if options.NoFlushOnFull && options.FlushInterval == 0 {
+		return ErrInvalidFlushOnFull
 	}
 	if options.PushTimeout < 0 {
-		return fmt.Errorf(ErrInvalidTimeout, "PushTimeout")
+		return invalidField(ErrInvalidTimeout, "PushTimeout")
 	}
 	if options.FlushTimeout < 0 {
-		return fmt.Errorf(ErrInvalidTimeout, "FlushTimeout")
+		return invalidField(ErrInvalidTimeout, "FlushTimeout")
+	}
+	if options.PerItemFlushTimeout < 0 {
+		return invalidField(ErrInvalidTimeout, "PerItemFlushTimeout")
 	}
 	if options.CloseTimeout < 0 {
-		return fmt.Errorf(ErrInvalidTimeout, "CloseTimeout")
+		return invalidField(ErrInvalidTimeout, "CloseTimeout")
+	}
+	if options.InitialDelay < 0 {
+		return invalidField(ErrInvalidInterval, "InitialDelay")
+	}
+	if options.DedupWindow < 0 {
+		return invalidField(ErrInvalidInterval, "DedupWindow")
+	}
+	if options.FlushDebounce < 0 {
+		return invalidField(ErrInvalidInterval, "FlushDebounce")
+	}
+	if options.HealthWindow < 0 {
+		return invalidField(ErrInvalidInterval, "HealthWindow")
+	}
+	if options.RetryBackoff < 0 {
+		return invalidField(ErrInvalidTimeout, "RetryBackoff")
+	}
+	if options.RetryJitter < 0 || options.RetryJitter > 1 {
+		return ErrInvalidRetryJitter
+	}
+	if options.RetryMaxElapsed < 0 {
+		return invalidField(ErrInvalidTimeout, "RetryMaxElapsed")
+	}
+	if options.RequeueAttempts < 0 {
+		return ErrInvalidRequeueAttempts
+	}
+	if options.SampleRate < 0 || options.SampleRate > 1 {
+		return ErrInvalidSampleRate
+	}
+	if options.MaxWriteBatch < 0 {
+		return ErrInvalidMaxWriteBatch
+	}
+	if options.MaxInFlightBatches < 0 {
+		return ErrInvalidMaxInFlightBatches
+	}
+	if options.MemoryLimit < 0 || options.MemoryLimit > 0 && options.SizeOf == nil {
+		return ErrInvalidMemoryLimit
+	}
+	if options.ChannelBuffer < 0 || options.ChannelBuffer > 0 && uint(options.ChannelBuffer) < options.Size {
+		return ErrInvalidChannelBuffer
+	}
+	if options.ErrChSize < 0 {
+		return ErrInvalidErrCh
+	}
+	if options.DistinctKeys < 0 || options.DistinctKeys > 0 && options.DistinctKey == nil {
+		return ErrInvalidDistinctKeys
+	}
+	if options.PushRetryAttempts < 0 || options.PushRetryAttempts > 0 && options.PushRetryBackoff <= 0 {
+		return ErrInvalidPushRetry
+	}
+	if options.Synchronous && (options.OverlappingFlush || options.FlushLaneKey != nil || options.NoFlushOnFull || options.ChannelBuffer > 0) {
+		return ErrInvalidSynchronousMode
+	}
+	if options.Watchdog < 0 || options.Watchdog > 0 && options.OnStuck == nil {
+		return ErrInvalidWatchdog
+	}
+	if high, low := options.waterMarks(); high > options.Size || low > high {
+		return ErrInvalidMarks
 	}
 
 	return nil
 }
+
+// invalidField wraps a validation error with the name of the offending field.
+func invalidField(err error, field string) error {
+	return fmt.Errorf("%w (%s)", err, field)
+}
@@ -1,6 +1,8 @@
 package buffer_test
 
 import (
+	"context"
+	"math/rand"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -24,7 +26,7 @@ var _ = Describe("Options", func() {
 	It("sets up flusher", func() {
 		// arrange
 		opts := buffer.New[any]()
-		flusher := func(items []interface{}) {}
+		flusher := func(items []interface{}) error { return nil }
 
 		// act
 		opts = opts.WithFlusher(buffer.FlusherFunc[any](flusher))
@@ -76,4 +78,580 @@ var _ = Describe("Options", func() {
 		// assert
 		Expect(opts.CloseTimeout).To(Equal(3 * time.Second))
 	})
+
+	It("sets up copy on flush", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithCopyOnFlush()
+
+		// assert
+		Expect(opts.CopyOnFlush).To(BeTrue())
+	})
+
+	It("sets up per-item flush timeout", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithPerItemFlushTimeout(50 * time.Millisecond)
+
+		// assert
+		Expect(opts.PerItemFlushTimeout).To(Equal(50 * time.Millisecond))
+	})
+
+	It("sets up water marks", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithWaterMarks(8, 4)
+
+		// assert
+		Expect(opts.HighWaterMark).To(BeIdenticalTo(uint(8)))
+		Expect(opts.LowWaterMark).To(BeIdenticalTo(uint(4)))
+	})
+
+	It("sets up batch validator", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithBatchValidator(func(items []any) error { return nil })
+
+		// assert
+		Expect(opts.BatchValidator).NotTo(BeNil())
+	})
+
+	It("sets up error handler", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithErrorHandler(func(err error, items []any) {})
+
+		// assert
+		Expect(opts.ErrorHandler).NotTo(BeNil())
+	})
+
+	It("sets up retries", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithRetries(3, time.Second)
+
+		// assert
+		Expect(opts.Retries).To(BeIdenticalTo(uint(3)))
+		Expect(opts.RetryBackoff).To(Equal(time.Second))
+	})
+
+	It("sets up dead letter", func() {
+		// arrange
+		opts := buffer.New[any]()
+		flusher := func(items []interface{}) error { return nil }
+
+		// act
+		opts = opts.WithDeadLetter(buffer.FlusherFunc[any](flusher))
+
+		// assert
+		Expect(opts.DeadLetter).NotTo(BeNil())
+	})
+
+	It("sets up overlapping flush", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithOverlappingFlush()
+
+		// assert
+		Expect(opts.OverlappingFlush).To(BeTrue())
+	})
+
+	It("sets up close hook", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithOnClose(func() {})
+
+		// assert
+		Expect(opts.OnClose).NotTo(BeNil())
+	})
+
+	It("sets up metrics", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithMetrics(&MockMetrics{})
+
+		// assert
+		Expect(opts.Metrics).NotTo(BeNil())
+	})
+
+	It("sets up zero value rejection", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithRejectZeroValue(nil)
+
+		// assert
+		Expect(opts.IsZero).NotTo(BeNil())
+		Expect(opts.IsZero(nil)).To(BeTrue())
+		Expect(opts.IsZero(0)).To(BeFalse())
+	})
+
+	It("sets up flush on close", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithFlushOnClose(false)
+
+		// assert
+		Expect(opts.DiscardOnClose).To(BeTrue())
+	})
+
+	It("sets up flush done signal", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithFlushDoneSignal()
+
+		// assert
+		Expect(opts.FlushDoneSignal).To(BeTrue())
+	})
+
+	It("sets up memory limit", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.
+			WithMemoryLimit(1024, func(any) int { return 1 }, func(any) {}).
+			WithMemoryPolicy(buffer.MemoryEvict)
+
+		// assert
+		Expect(opts.MemoryLimit).To(Equal(1024))
+		Expect(opts.SizeOf).NotTo(BeNil())
+		Expect(opts.OnEvict).NotTo(BeNil())
+		Expect(opts.MemoryPolicy).To(Equal(buffer.MemoryEvict))
+	})
+
+	It("sets up initial delay", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithInitialDelay(2 * time.Second)
+
+		// assert
+		Expect(opts.InitialDelay).To(Equal(2 * time.Second))
+	})
+
+	It("sets up context flusher", func() {
+		// arrange
+		opts := buffer.New[any]()
+		flusher := func(ctx context.Context, items []any) error { return nil }
+
+		// act
+		opts = opts.WithContextFlusher(buffer.ContextFlusherFunc[any](flusher))
+
+		// assert
+		Expect(opts.ContextFlusher).NotTo(BeNil())
+	})
+
+	It("sets up requeue on error", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithRequeueOnError(3)
+
+		// assert
+		Expect(opts.RequeueAttempts).To(Equal(3))
+	})
+
+	It("sets up flusher selector", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithFlusherSelector(func(items []any) buffer.Flusher[any] { return nil })
+
+		// assert
+		Expect(opts.FlusherSelector).NotTo(BeNil())
+	})
+
+	It("sets up explicit start", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithExplicitStart()
+
+		// assert
+		Expect(opts.RequireStart).To(BeTrue())
+	})
+
+	It("sets up sampling", func() {
+		// arrange
+		opts := buffer.New[any]()
+		src := rand.NewSource(1)
+
+		// act
+		opts = opts.
+			WithSampleRate(0.1).
+			WithRandSource(src)
+
+		// assert
+		Expect(opts.SampleRate).To(Equal(0.1))
+		Expect(opts.RandSource).To(BeIdenticalTo(src))
+	})
+
+	It("sets up health threshold", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithHealthThreshold(3, time.Minute)
+
+		// assert
+		Expect(opts.HealthFailures).To(BeIdenticalTo(uint(3)))
+		Expect(opts.HealthWindow).To(Equal(time.Minute))
+	})
+
+	It("sets up flush grouping", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		buffer.WithFlushGroupBy(func(item any) any { return item })(opts)
+
+		// assert
+		Expect(opts.FlushGroupKey).NotTo(BeNil())
+	})
+
+	It("sets up batch transform", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithBatchTransform(func(items []any) []any { return items })
+
+		// assert
+		Expect(opts.BatchTransform).NotTo(BeNil())
+	})
+
+	It("sets up exclusive flush", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithExclusiveFlush()
+
+		// assert
+		Expect(opts.ExclusiveFlush).To(BeTrue())
+	})
+
+	It("sets up dedup window", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		buffer.WithDedupWindow(func(item any) any { return item }, time.Minute)(opts)
+
+		// assert
+		Expect(opts.DedupKey).NotTo(BeNil())
+		Expect(opts.DedupWindow).To(Equal(time.Minute))
+	})
+
+	It("sets up closed push behavior", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithClosedPushBehavior(buffer.SilentlyDrop)
+
+		// assert
+		Expect(opts.ClosedPushBehavior).To(Equal(buffer.SilentlyDrop))
+	})
+
+	It("sets up stable interval", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithStableInterval()
+
+		// assert
+		Expect(opts.StableInterval).To(BeTrue())
+	})
+
+	It("sets up timeout error", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithTimeoutError(func(op string) error { return nil })
+
+		// assert
+		Expect(opts.TimeoutError).NotTo(BeNil())
+	})
+
+	It("sets up max write batch", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithMaxWriteBatch(500)
+
+		// assert
+		Expect(opts.MaxWriteBatch).To(Equal(500))
+	})
+
+	It("sets up max in-flight batches", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithMaxInFlightBatches(4)
+
+		// assert
+		Expect(opts.MaxInFlightBatches).To(Equal(4))
+	})
+
+	It("sets up panic handler", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithPanicHandler(func(recovered any, stack []byte) {})
+
+		// assert
+		Expect(opts.PanicHandler).NotTo(BeNil())
+	})
+
+	It("sets up timestamps", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithTimestamps(buffer.TimestampedFlusherFunc[any](func(items []buffer.TimestampedItem[any]) error { return nil }))
+
+		// assert
+		Expect(opts.TimestampedFlusher).NotTo(BeNil())
+	})
+
+	It("sets up flush debounce", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithFlushDebounce(50 * time.Millisecond)
+
+		// assert
+		Expect(opts.FlushDebounce).To(Equal(50 * time.Millisecond))
+	})
+
+	It("sets up partial flusher", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithPartialFlusher(buffer.PartialFlusherFunc[any](func(items []any) ([]any, error) { return nil, nil }))
+
+		// assert
+		Expect(opts.PartialFlusher).NotTo(BeNil())
+	})
+
+	It("sets up flush on full", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithFlushOnFull(false)
+
+		// assert
+		Expect(opts.NoFlushOnFull).To(BeTrue())
+	})
+
+	It("sets up flush trigger", func() {
+		// arrange
+		opts := buffer.New[any]()
+		var trigger <-chan struct{} = make(chan struct{})
+
+		// act
+		opts = opts.WithFlushTrigger(trigger)
+
+		// assert
+		Expect(opts.FlushTrigger).To(Equal(trigger))
+	})
+
+	It("sets up flush order", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithFlushOrder(buffer.LIFO)
+
+		// assert
+		Expect(opts.FlushOrder).To(Equal(buffer.LIFO))
+	})
+
+	It("sets up flusher close", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithFlusherClose()
+
+		// assert
+		Expect(opts.CloseFlusher).To(BeTrue())
+	})
+
+	It("sets up watchdog", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithWatchdog(time.Minute, func() {})
+
+		// assert
+		Expect(opts.Watchdog).To(Equal(time.Minute))
+		Expect(opts.OnStuck).NotTo(BeNil())
+	})
+
+	It("sets up last value per key", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		buffer.WithLastValuePerKey(func(item any) any { return item })(opts)
+
+		// assert
+		Expect(opts.LastValueKey).NotTo(BeNil())
+	})
+
+	It("sets up distinct key trigger", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		buffer.WithDistinctKeyTrigger(func(item any) any { return item }, 3)(opts)
+
+		// assert
+		Expect(opts.DistinctKey).NotTo(BeNil())
+		Expect(opts.DistinctKeys).To(Equal(3))
+	})
+
+	It("sets up push hook", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithPushHook(func(item any, accepted bool) {})
+
+		// assert
+		Expect(opts.PushHook).NotTo(BeNil())
+	})
+
+	It("sets up retry jitter and max elapsed time", func() {
+		// arrange
+		opts := buffer.New[any]()
+		src := rand.NewSource(1)
+
+		// act
+		opts = opts.
+			WithRetryJitter(0.5).
+			WithRetryMaxElapsed(time.Minute).
+			WithRetryRandSource(src)
+
+		// assert
+		Expect(opts.RetryJitter).To(Equal(0.5))
+		Expect(opts.RetryMaxElapsed).To(Equal(time.Minute))
+		Expect(opts.RetryRandSource).To(BeIdenticalTo(src))
+	})
+
+	It("sets up item validator", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithItemValidator(func(item any) error { return nil })
+
+		// assert
+		Expect(opts.ItemValidator).NotTo(BeNil())
+	})
+
+	It("sets up channel buffer", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithChannelBuffer(64)
+
+		// assert
+		Expect(opts.ChannelBuffer).To(Equal(64))
+	})
+
+	It("sets up strict size", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithStrictSize()
+
+		// assert
+		Expect(opts.StrictSize).To(BeTrue())
+	})
+
+	It("sets up error channel", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithErrCh(8)
+
+		// assert
+		Expect(opts.ErrChSize).To(Equal(8))
+	})
+
+	It("sets up synchronous mode", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithSynchronousMode()
+
+		// assert
+		Expect(opts.Synchronous).To(BeTrue())
+	})
+
+	It("sets up empty flush hook", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithOnEmptyFlush(func(reason buffer.FlushReason) {})
+
+		// assert
+		Expect(opts.OnEmptyFlush).NotTo(BeNil())
+	})
+
+	It("sets up skipping cancelled items", func() {
+		// arrange
+		opts := buffer.New[any]()
+
+		// act
+		opts = opts.WithSkipCancelled()
+
+		// assert
+		Expect(opts.SkipCancelled).To(BeTrue())
+	})
 })
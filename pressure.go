@@ -0,0 +1,58 @@
+package buffer
+
+import "sync"
+
+type pressure struct {
+	once      sync.Once
+	ch        chan bool
+	pressured bool
+}
+
+// PressureSignal returns a channel that receives true when the number of
+// buffered items reaches the high-water mark and false once it drops below the
+// low-water mark again.
+//
+// Signals are coalesced: the channel holds at most one value, and a value that
+// has not been received yet is replaced by the latest state. The channel is
+// closed when the buffer is closed.
+func (buffer *Buffer[T]) PressureSignal() <-chan bool {
+	return buffer.pressure.signal()
+}
+
+func (p *pressure) signal() chan bool {
+	p.once.Do(func() {
+		p.ch = make(chan bool, 1)
+	})
+
+	return p.ch
+}
+
+// update is called by the consume goroutine whenever the pending count changes.
+func (p *pressure) update(count, high, low uint) {
+	switch {
+	case !p.pressured && count >= high:
+		p.pressured = true
+	case p.pressured && count < low:
+		p.pressured = false
+	default:
+		return
+	}
+
+	ch := p.signal()
+	for {
+		select {
+		case ch <- p.pressured:
+			return
+		default:
+		}
+
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
+
+func (p *pressure) close() {
+	close(p.signal())
+}
@@ -0,0 +1,100 @@
+package buffer_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/omniboost/go-buffer"
+)
+
+var _ = Describe("Pressure", func() {
+	var flusher *MockFlusher[any]
+
+	BeforeEach(func() {
+		flusher = NewMockFlusher[any]()
+	})
+
+	It("signals when the high-water mark is reached and released", func() {
+		// arrange
+		release := make(chan struct{})
+		flusher.Func = func() { <-release }
+		sut := buffer.New[any]().
+			WithSize(4).
+			WithFlusher(flusher).
+			WithWaterMarks(2, 1)
+
+		signal := sut.PressureSignal()
+
+		// act
+		err := sut.Push(1)
+		err1 := sut.Push(2)
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(err1).To(Succeed())
+		Eventually(signal).Should(Receive(BeTrue()))
+
+		Expect(sut.Flush()).To(Succeed())
+		close(release)
+		Eventually(signal).Should(Receive(BeFalse()))
+		_ = sut.Close()
+	})
+
+	It("signals pressure before the buffer is full by default", func() {
+		// arrange
+		sut := buffer.New[any]().
+			WithSize(5).
+			WithFlusher(flusher)
+
+		signal := sut.PressureSignal()
+
+		// act
+		for i := range 4 {
+			Expect(sut.Push(i)).To(Succeed())
+		}
+
+		// assert
+		Eventually(signal).Should(Receive(BeTrue()))
+		_ = sut.Close()
+	})
+
+	It("coalesces signals that have not been received", func() {
+		// arrange
+		sut := buffer.New[any]().
+			WithSize(2).
+			WithFlusher(flusher).
+			WithWaterMarks(1, 1)
+
+		signal := sut.PressureSignal()
+
+		// act
+		_ = sut.Push(1)
+		Expect(sut.Flush()).To(Succeed())
+		<-flusher.Done
+		time.Sleep(10 * time.Millisecond)
+
+		// assert
+		Expect(signal).To(Receive(BeFalse()))
+		Expect(signal).NotTo(Receive())
+		_ = sut.Close()
+	})
+
+	It("closes the signal channel when the buffer is closed", func() {
+		// arrange
+		sut := buffer.New[any]().
+			WithSize(2).
+			WithFlusher(flusher)
+
+		signal := sut.PressureSignal()
+		_ = sut.Push(1)
+
+		// act
+		err := sut.Close()
+
+		// assert
+		Expect(err).To(Succeed())
+		Eventually(signal).Should(BeClosed())
+	})
+})
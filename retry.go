@@ -0,0 +1,181 @@
+package buffer
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+type (
+	// retryBackoff computes the delays between retries: the delay doubles after
+	// every attempt and is shortened by up to jitter times itself, and retries
+	// stop once they would take longer than maxElapsed in total.
+	retryBackoff struct {
+		delay      time.Duration
+		jitter     float64
+		maxElapsed time.Duration
+		random     func() float64
+		start      time.Time
+	}
+
+	// lockedRand makes a source of randomness safe for concurrent flushes,
+	// falling back to the global source when there is none.
+	lockedRand struct {
+		mu   sync.Mutex
+		rand *rand.Rand
+	}
+)
+
+// write hands a batch to the flusher, retrying with an exponential backoff
+// until it succeeds or the configured number of retries is exhausted. A panic
+// is recovered only if there is a panic handler, and ends the retries.
+//
+// It returns the items that could not be written along with the error, which
+// is every item unless the flusher is a partial flusher.
+func (buffer *Buffer[T]) write(ctx context.Context, items []T, stamps []time.Time) (failed []T, err error) {
+	if buffer.PanicHandler != nil {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				buffer.PanicHandler(recovered, debug.Stack())
+				failed, err = items, ErrFlusherPanicked
+			}
+		}()
+	}
+
+	var flusher Flusher[T] = buffer.Flusher
+	switch {
+	case buffer.TimestampedFlusher != nil:
+		flusher = FlusherFunc[T](func(items []T) error {
+			return buffer.TimestampedFlusher.WriteTimestamped(timestamp(items, stamps))
+		})
+	case buffer.FlusherSelector != nil:
+		flusher = buffer.FlusherSelector(items)
+		if flusher == nil {
+			return items, ErrNoFlusher
+		}
+	case buffer.ContextFlusher != nil:
+		flusher = FlusherFunc[T](func(items []T) error {
+			return buffer.ContextFlusher.Write(ctx, items)
+		})
+	}
+
+	if buffer.ExclusiveFlush {
+		buffer.writeMu.Lock()
+		defer buffer.writeMu.Unlock()
+	}
+
+	if buffer.PartialFlusher != nil && buffer.TimestampedFlusher == nil {
+		return retryPartial(buffer.PartialFlusher, items, buffer.Retries, buffer.backoff())
+	}
+
+	err = retry(flusher, items, buffer.Retries, buffer.backoff())
+	if err != nil {
+		failed = items
+	}
+
+	return failed, err
+}
+
+func retry[T any](flusher Flusher[T], items []T, retries uint, backoff retryBackoff) error {
+	backoff.start = time.Now()
+
+	err := flusher.Write(items)
+	for attempt := uint(0); err != nil && attempt < retries && backoff.wait(); attempt++ {
+		err = flusher.Write(items)
+	}
+
+	return err
+}
+
+// retryPartial behaves like retry, retrying only the items that failed.
+func retryPartial[T any](flusher PartialFlusher[T], items []T, retries uint, backoff retryBackoff) ([]T, error) {
+	backoff.start = time.Now()
+
+	failed, err := writePartial(flusher, items)
+	for attempt := uint(0); err != nil && attempt < retries && backoff.wait(); attempt++ {
+		failed, err = writePartial(flusher, failed)
+	}
+
+	return failed, err
+}
+
+// backoff returns the backoff for the retries of a single write.
+func (buffer *Buffer[T]) backoff() retryBackoff {
+	return retryBackoff{
+		delay:      buffer.RetryBackoff,
+		jitter:     buffer.RetryJitter,
+		maxElapsed: buffer.RetryMaxElapsed,
+		random:     buffer.retryRand.float64,
+	}
+}
+
+// wait sleeps until the next retry, reporting false without sleeping when the
+// retry would end past the maximum elapsed time.
+func (b *retryBackoff) wait() bool {
+	delay := b.delay
+	b.delay *= 2
+	if b.jitter > 0 {
+		delay -= time.Duration(b.jitter * b.random() * float64(delay))
+	}
+	if b.maxElapsed > 0 && time.Since(b.start)+delay > b.maxElapsed {
+		return false
+	}
+
+	time.Sleep(delay)
+	return true
+}
+
+func (r *lockedRand) float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.rand == nil {
+		return rand.Float64()
+	}
+
+	return r.rand.Float64()
+}
+
+// writePartial writes items to a partial flusher, considering every item failed
+// when it returns an error without failed items, and failing with an
+// ErrPartialWrite when it returns failed items without an error.
+func writePartial[T any](flusher PartialFlusher[T], items []T) ([]T, error) {
+	failed, err := flusher.Write(items)
+	switch {
+	case err != nil && len(failed) == 0:
+		return items, err
+	case err == nil && len(failed) > 0:
+		return failed, ErrPartialWrite
+	}
+
+	return failed, err
+}
+
+// fail hands a batch that could not be written to the dead-letter flusher,
+// falling back to the error handler if there is none or if it fails as well.
+func (buffer *Buffer[T]) fail(err error, items []T) {
+	if buffer.DeadLetter != nil {
+		dlErr := buffer.DeadLetter.Write(items)
+		if dlErr == nil {
+			return
+		}
+		err = errors.Join(err, dlErr)
+	}
+
+	if buffer.ErrorHandler != nil {
+		buffer.ErrorHandler(err, items)
+	}
+}
+
+// requeues reports whether a batch that failed with err is put back into the
+// buffer rather than failed right away. Invalid batches, batches no flusher was
+// selected for, and batches the flusher panicked on are never requeued.
+func (buffer *Buffer[T]) requeues(err error) bool {
+	return buffer.RequeueAttempts > 0 &&
+		!errors.Is(err, ErrInvalidBatch) &&
+		!errors.Is(err, ErrNoFlusher) &&
+		!errors.Is(err, ErrFlusherPanicked)
+}
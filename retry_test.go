@@ -0,0 +1,152 @@
+package buffer_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/omniboost/go-buffer"
+)
+
+var _ = Describe("Retries", func() {
+	var deadLetter *MockFlusher[any]
+
+	BeforeEach(func() {
+		deadLetter = NewMockFlusher[any]()
+	})
+
+	It("retries a failed write until it succeeds", func() {
+		// arrange
+		var attempts atomic.Int32
+		written := make(chan []any, 1)
+		sut := buffer.New[any]().
+			WithSize(2).
+			WithRetries(3, time.Millisecond).
+			WithFlusher(buffer.FlusherFunc[any](func(items []any) error {
+				if attempts.Add(1) < 3 {
+					return errors.New("sink is down")
+				}
+				written <- items
+				return nil
+			}))
+
+		// act
+		err := sut.Push(1)
+		_ = sut.Push(2)
+
+		// assert
+		Expect(err).To(Succeed())
+		Eventually(written).Should(Receive(Equal([]any{1, 2})))
+		Expect(attempts.Load()).To(Equal(int32(3)))
+		_ = sut.Close()
+	})
+
+	It("routes the batch to the dead-letter flusher when retries are exhausted", func() {
+		// arrange
+		var attempts atomic.Int32
+		sut := buffer.New[any]().
+			WithSize(2).
+			WithRetries(2, time.Millisecond).
+			WithDeadLetter(deadLetter).
+			WithErrorHandler(func(err error, items []any) { Fail("unexpected error handler call") }).
+			WithFlusher(buffer.FlusherFunc[any](func(items []any) error {
+				attempts.Add(1)
+				return errors.New("sink is down")
+			}))
+
+		// act
+		err := sut.Push(1)
+		_ = sut.Push(2)
+
+		// assert
+		Expect(err).To(Succeed())
+		var result *WriteCall[any]
+		Eventually(deadLetter.Done).Should(Receive(&result))
+		Expect(result.Items).To(Equal([]any{1, 2}))
+		Expect(attempts.Load()).To(Equal(int32(3)))
+		_ = sut.Close()
+	})
+
+	It("calls the error handler when the dead-letter flusher fails too", func() {
+		// arrange
+		failed := make(chan error, 1)
+		deadLetter.Err = errors.New("disk is full")
+		sut := buffer.New[any]().
+			WithSize(1).
+			WithDeadLetter(deadLetter).
+			WithErrorHandler(func(err error, items []any) { failed <- err }).
+			WithFlusher(buffer.FlusherFunc[any](func(items []any) error {
+				return errors.New("sink is down")
+			}))
+
+		// act
+		err := sut.Push(1)
+
+		// assert
+		Expect(err).To(Succeed())
+		Eventually(failed).Should(Receive(And(
+			MatchError(ContainSubstring("sink is down")),
+			MatchError(ContainSubstring("disk is full")),
+		)))
+		_ = sut.Close()
+	})
+
+	It("shortens the backoff by the retry jitter", func() {
+		// arrange
+		var attempts atomic.Int32
+		failed := make(chan error, 1)
+		sut := buffer.New[any]().
+			WithSize(1).
+			WithRetries(2, time.Hour).
+			WithRetryJitter(1).
+			WithRetryRandSource(almostOneSource{}).
+			WithErrorHandler(func(err error, items []any) { failed <- err }).
+			WithFlusher(buffer.FlusherFunc[any](func(items []any) error {
+				attempts.Add(1)
+				return errors.New("sink is down")
+			}))
+
+		// act
+		err := sut.Push(1)
+
+		// assert
+		Expect(err).To(Succeed())
+		Eventually(failed).Should(Receive())
+		Expect(attempts.Load()).To(Equal(int32(3)))
+		_ = sut.Close()
+	})
+
+	It("gives up once retrying takes longer than the max elapsed time", func() {
+		// arrange
+		var attempts atomic.Int32
+		failed := make(chan error, 1)
+		sut := buffer.New[any]().
+			WithSize(1).
+			WithRetries(10, 20*time.Millisecond).
+			WithRetryMaxElapsed(50 * time.Millisecond).
+			WithErrorHandler(func(err error, items []any) { failed <- err }).
+			WithFlusher(buffer.FlusherFunc[any](func(items []any) error {
+				attempts.Add(1)
+				return errors.New("sink is down")
+			}))
+
+		// act
+		err := sut.Push(1)
+
+		// assert
+		Expect(err).To(Succeed())
+		Eventually(failed).Should(Receive())
+		Expect(attempts.Load()).To(Equal(int32(2)))
+		_ = sut.Close()
+	})
+})
+
+// almostOneSource is a source of randomness whose floats are as close to 1 as
+// they get.
+type almostOneSource struct{}
+
+func (almostOneSource) Int63() int64 { return 1<<63 - 1<<12 }
+func (almostOneSource) Seed(int64)   {}
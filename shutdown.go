@@ -0,0 +1,87 @@
+package buffer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// CloseAll closes every closer one after the other, in the order given, so
+// buffers can be shut down in dependency order. It keeps going when a closer
+// fails and returns all errors joined together. Buffers that were never
+// initialized are skipped rather than reported with ErrNotInitialized.
+//
+// Once the context is done, the closers that have not been closed yet are left
+// alone and the context's error is joined to the result. A Close in progress is
+// not interrupted, it is merely no longer waited for.
+func CloseAll(ctx context.Context, closers ...io.Closer) error {
+	var errs []error
+	for _, closer := range closers {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		done := make(chan error, 1)
+		go func() { done <- closeOne(closer) }()
+
+		select {
+		case err := <-done:
+			errs = append(errs, err)
+		case <-ctx.Done():
+			errs = append(errs, ctx.Err())
+			return errors.Join(errs...)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// CloseAllConcurrently closes every closer at the same time, and returns all
+// errors joined together like CloseAll. Once the context is done, it stops
+// waiting for the closers that have not returned yet and joins the context's
+// error to the result.
+func CloseAllConcurrently(ctx context.Context, closers ...io.Closer) error {
+	var (
+		mu   sync.Mutex
+		errs = make([]error, len(closers))
+		wg   sync.WaitGroup
+	)
+	for i, closer := range closers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := closeOne(closer)
+			mu.Lock()
+			errs[i] = err
+			mu.Unlock()
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var ctxErr error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		ctxErr = ctx.Err()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return errors.Join(append(errs, ctxErr)...)
+}
+
+func closeOne(closer io.Closer) error {
+	err := closer.Close()
+	if errors.Is(err, ErrNotInitialized) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,116 @@
+package buffer_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/omniboost/go-buffer"
+)
+
+type closerFunc func() error
+
+func (fn closerFunc) Close() error { return fn() }
+
+var _ = Describe("Shutdown", func() {
+	Context("CloseAll", func() {
+		It("closes every buffer in order and joins the errors", func() {
+			// arrange
+			errFirst := errors.New("first")
+			var (
+				mu    sync.Mutex
+				order []string
+			)
+			closer := func(name string, err error) io.Closer {
+				return closerFunc(func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					order = append(order, name)
+					return err
+				})
+			}
+			flushed := make(chan []int, 1)
+			buf := buffer.New[int]().
+				WithSize(2).
+				WithFlusher(buffer.NewChannelFlusher[int](flushed, 0))
+			unused := buffer.New[int]().
+				WithSize(2).
+				WithFlusher(buffer.NewChannelFlusher[int](flushed, 0))
+			_ = buf.Push(1)
+
+			// act
+			err := buffer.CloseAll(context.Background(), closer("a", errFirst), buf, unused, closer("b", nil))
+
+			// assert
+			Expect(err).To(MatchError(errFirst))
+			Expect(order).To(Equal([]string{"a", "b"}))
+			Expect(flushed).To(Receive(Equal([]int{1})))
+		})
+
+		It("stops closing once the context is done", func() {
+			// arrange
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			closed := false
+			slow := closerFunc(func() error {
+				time.Sleep(200 * time.Millisecond)
+				return nil
+			})
+			next := closerFunc(func() error {
+				closed = true
+				return nil
+			})
+
+			// act
+			err := buffer.CloseAll(ctx, slow, next)
+
+			// assert
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+			Expect(closed).To(BeFalse())
+		})
+	})
+
+	Context("CloseAllConcurrently", func() {
+		It("closes every buffer at the same time", func() {
+			// arrange
+			errSecond := errors.New("second")
+			slow := func(err error) io.Closer {
+				return closerFunc(func() error {
+					time.Sleep(100 * time.Millisecond)
+					return err
+				})
+			}
+
+			// act
+			start := time.Now()
+			err := buffer.CloseAllConcurrently(context.Background(), slow(nil), slow(errSecond), slow(nil))
+
+			// assert
+			Expect(err).To(MatchError(errSecond))
+			Expect(time.Since(start)).To(BeNumerically("<", 250*time.Millisecond))
+		})
+
+		It("stops waiting once the context is done", func() {
+			// arrange
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			slow := closerFunc(func() error {
+				time.Sleep(time.Second)
+				return nil
+			})
+
+			// act
+			start := time.Now()
+			err := buffer.CloseAllConcurrently(ctx, slow)
+
+			// assert
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+			Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+		})
+	})
+})
@@ -0,0 +1,54 @@
+package buffer
+
+import (
+	"context"
+	"errors"
+)
+
+// Snapshot encodes the items currently buffered with encode, for instance to
+// carry them over to a new buffer across a restart with RestoreSnapshot. The
+// items stay buffered, so a buffer that is closed afterwards still writes them
+// unless it is closed with WithFlushOnClose(false).
+//
+// The snapshot is taken by the consume goroutine in between flushes: an inline
+// flush in progress completes first and its items are not part of the
+// snapshot, and neither are the items being written by overlapping flushes,
+// unless they are requeued before the snapshot is taken.
+//
+// It returns an ErrTimeout if the snapshot cannot be taken within the flush
+// timeout, an ErrNotInitialized if nothing has been pushed yet, an ErrClosed if
+// the buffer has been closed, and the error of encode otherwise.
+func (buffer *Buffer[T]) Snapshot(encode func(items []T) ([]byte, error)) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), buffer.flushTimeout())
+	defer cancel()
+
+	items, err := buffer.awaitFlush(ctx, flushRequest[T]{snapshot: true})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, buffer.timeoutError("snapshot", errors.New("failed to take snapshot within flush timeout"))
+		}
+		return nil, err
+	}
+
+	return encode(items)
+}
+
+// RestoreSnapshot decodes a snapshot taken with Snapshot and pushes its items
+// in order, as if they were pushed one by one with Push.
+//
+// It returns the error of decode, or the error of the first push that fails,
+// in which case the items before it have been pushed and the others have not.
+func (buffer *Buffer[T]) RestoreSnapshot(data []byte, decode func(data []byte) ([]T, error)) error {
+	items, err := decode(data)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err := buffer.Push(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package buffer_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/omniboost/go-buffer"
+)
+
+var _ = Describe("Snapshots", func() {
+	encode := func(items []int) ([]byte, error) { return json.Marshal(items) }
+	decode := func(data []byte) ([]int, error) {
+		var items []int
+		err := json.Unmarshal(data, &items)
+		return items, err
+	}
+
+	It("carries the buffered items over to another buffer", func() {
+		// arrange
+		batches := make(chan []int, 1)
+		sut := buffer.New[int]().
+			WithSize(3).
+			WithFlusher(buffer.FlusherFunc[int](func([]int) error { return nil })).
+			WithFlushOnClose(false)
+		restored := buffer.New[int]().
+			WithSize(3).
+			WithFlusher(buffer.NewChannelFlusher[int](batches, 0))
+
+		err := sut.Push(1)
+		_ = sut.Push(2)
+
+		// act
+		data, err1 := sut.Snapshot(encode)
+		err2 := restored.RestoreSnapshot(data, decode)
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(err1).To(Succeed())
+		Expect(err2).To(Succeed())
+		Expect(sut.Len()).To(Equal(2))
+		Expect(restored.Flush()).To(Succeed())
+		Eventually(batches).Should(Receive(Equal([]int{1, 2})))
+		_ = sut.Close()
+		_ = restored.Close()
+	})
+
+	It("fails when the snapshot cannot be decoded", func() {
+		// arrange
+		sut := buffer.New[int]().
+			WithSize(3).
+			WithFlusher(buffer.FlusherFunc[int](func([]int) error { return nil }))
+
+		// act
+		err := sut.RestoreSnapshot([]byte("{"), decode)
+
+		// assert
+		Expect(err).To(HaveOccurred())
+		Expect(sut.IsIntialized()).To(BeFalse())
+	})
+
+	It("fails when the items cannot be encoded", func() {
+		// arrange
+		encodeErr := errors.New("cannot encode")
+		sut := buffer.New[int]().
+			WithSize(3).
+			WithFlusher(buffer.FlusherFunc[int](func([]int) error { return nil }))
+
+		err := sut.Push(1)
+
+		// act
+		_, err1 := sut.Snapshot(func([]int) ([]byte, error) { return nil, encodeErr })
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(err1).To(MatchError(encodeErr))
+		_ = sut.Close()
+	})
+
+	It("fails when the buffer is not initialized", func() {
+		// arrange
+		sut := buffer.New[int]().
+			WithSize(3).
+			WithFlusher(buffer.FlusherFunc[int](func([]int) error { return nil }))
+
+		// act
+		_, err := sut.Snapshot(encode)
+
+		// assert
+		Expect(err).To(MatchError(buffer.ErrNotInitialized))
+	})
+})
@@ -0,0 +1,76 @@
+package buffer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+)
+
+// FromReader reads tokens from r and pushes each of them into the buffer.
+//
+// Tokens are delimited by split, which defaults to bufio.ScanLines when nil.
+// Every token is copied before it is pushed, since the scanner reuses its
+// internal storage. It returns nil once r is exhausted, the first push or read
+// error otherwise, and the context's error if the context is done before r is
+// exhausted. A read that blocks is not interrupted by the context.
+func FromReader(ctx context.Context, r io.Reader, split bufio.SplitFunc, b *Buffer[[]byte]) error {
+	scanner := bufio.NewScanner(r)
+	if split != nil {
+		scanner.Split(split)
+	}
+
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		token := append([]byte(nil), scanner.Bytes()...)
+		if err := b.Push(token); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// FromChannel receives items from src and pushes each of them into the buffer,
+// closing the buffer once src is closed or the context is done.
+//
+// When stopOnError is set it stops at the first push error, closes the buffer
+// and returns that error. Otherwise it keeps going and returns the first push
+// error once src is closed. It returns the context's error if the context is
+// done before src is closed, and the close error if the buffer cannot be
+// closed.
+func FromChannel[T any](ctx context.Context, src <-chan T, b *Buffer[T], stopOnError bool) error {
+	var err error
+	for {
+		select {
+		case item, ok := <-src:
+			if !ok {
+				return errors.Join(err, closeSource(b))
+			}
+			if pushErr := b.Push(item); pushErr != nil {
+				if stopOnError {
+					return errors.Join(pushErr, closeSource(b))
+				}
+				if err == nil {
+					err = pushErr
+				}
+			}
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err(), closeSource(b))
+		}
+	}
+}
+
+// closeSource closes a buffer fed by a source, ignoring that it might not have
+// been initialized because nothing was pushed.
+func closeSource[T any](b *Buffer[T]) error {
+	err := b.Close()
+	if errors.Is(err, ErrNotInitialized) {
+		return nil
+	}
+
+	return err
+}
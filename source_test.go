@@ -0,0 +1,166 @@
+package buffer_test
+
+import (
+	"bufio"
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/omniboost/go-buffer"
+)
+
+var _ = Describe("Sources", func() {
+	Context("FromReader", func() {
+		It("pushes every token into the buffer", func() {
+			// arrange
+			flusher := NewMockFlusher[[]byte]()
+			sut := buffer.New[[]byte]().
+				WithSize(3).
+				WithFlusher(flusher)
+
+			// act
+			err := buffer.FromReader(context.Background(), strings.NewReader("a\nb\nc\n"), nil, sut)
+
+			// assert
+			Expect(err).To(Succeed())
+			result := <-flusher.Done
+			Expect(result.Items).To(Equal([][]byte{[]byte("a"), []byte("b"), []byte("c")}))
+			_ = sut.Close()
+		})
+
+		It("splits the input with the provided split function", func() {
+			// arrange
+			flusher := NewMockFlusher[[]byte]()
+			sut := buffer.New[[]byte]().
+				WithSize(2).
+				WithFlusher(flusher)
+
+			// act
+			err := buffer.FromReader(context.Background(), strings.NewReader("a b"), bufio.ScanWords, sut)
+
+			// assert
+			Expect(err).To(Succeed())
+			result := <-flusher.Done
+			Expect(result.Items).To(Equal([][]byte{[]byte("a"), []byte("b")}))
+			_ = sut.Close()
+		})
+
+		It("propagates push errors", func() {
+			// arrange
+			sut := buffer.New[[]byte]().
+				WithSize(0)
+
+			// act
+			err := buffer.FromReader(context.Background(), strings.NewReader("a\n"), nil, sut)
+
+			// assert
+			Expect(err).To(MatchError(buffer.ErrInvalidSize))
+		})
+
+		It("stops when the context is done", func() {
+			// arrange
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			sut := buffer.New[[]byte]().
+				WithSize(2).
+				WithFlusher(NewMockFlusher[[]byte]())
+
+			// act
+			err := buffer.FromReader(ctx, strings.NewReader("a\n"), nil, sut)
+
+			// assert
+			Expect(err).To(MatchError(context.Canceled))
+		})
+	})
+
+	Context("FromChannel", func() {
+		It("pushes every item into the buffer and closes it with the channel", func() {
+			// arrange
+			flusher := NewMockFlusher[int]()
+			sut := buffer.New[int]().
+				WithSize(5).
+				WithFlusher(flusher)
+
+			src := make(chan int, 3)
+			src <- 1
+			src <- 2
+			src <- 3
+			close(src)
+
+			// act
+			err := buffer.FromChannel(context.Background(), src, sut, true)
+
+			// assert
+			Expect(err).To(Succeed())
+			result := <-flusher.Done
+			Expect(result.Items).To(Equal([]int{1, 2, 3}))
+			Expect(sut.Push(4)).To(MatchError(buffer.ErrClosed))
+		})
+
+		It("stops at the first push error when asked to", func() {
+			// arrange
+			flusher := NewMockFlusher[int]()
+			sut := buffer.New[int]().
+				WithSize(5).
+				WithFlusher(flusher).
+				WithRejectZeroValue(nil)
+
+			src := make(chan int, 3)
+			src <- 1
+			src <- 0
+			src <- 2
+			close(src)
+
+			// act
+			err := buffer.FromChannel(context.Background(), src, sut, true)
+
+			// assert
+			Expect(err).To(MatchError(buffer.ErrZeroValue))
+			result := <-flusher.Done
+			Expect(result.Items).To(Equal([]int{1}))
+		})
+
+		It("keeps going after a push error otherwise", func() {
+			// arrange
+			flusher := NewMockFlusher[int]()
+			sut := buffer.New[int]().
+				WithSize(5).
+				WithFlusher(flusher).
+				WithRejectZeroValue(nil)
+
+			src := make(chan int, 3)
+			src <- 1
+			src <- 0
+			src <- 2
+			close(src)
+
+			// act
+			err := buffer.FromChannel(context.Background(), src, sut, false)
+
+			// assert
+			Expect(err).To(MatchError(buffer.ErrZeroValue))
+			result := <-flusher.Done
+			Expect(result.Items).To(Equal([]int{1, 2}))
+		})
+
+		It("closes the buffer when the context is done", func() {
+			// arrange
+			flusher := NewMockFlusher[int]()
+			sut := buffer.New[int]().
+				WithSize(5).
+				WithFlusher(flusher)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			src := make(chan int)
+			cancel()
+
+			// act
+			err := buffer.FromChannel(ctx, src, sut, true)
+
+			// assert
+			Expect(err).To(MatchError(context.Canceled))
+		})
+	})
+})
@@ -0,0 +1,96 @@
+package buffer
+
+import (
+	"errors"
+	"time"
+)
+
+type (
+	// Stats represents a snapshot of the buffer's counters.
+	Stats struct {
+		// Pending is the number of items currently buffered.
+		Pending int
+		// InFlight is the number of batches currently being written by
+		// overlapping flushes, see WithMaxInFlightBatches.
+		InFlight int
+		// Pushed is the total number of items accepted by the buffer.
+		Pushed uint64
+		// Flushes is the total number of batches handed to the flusher.
+		Flushes uint64
+		// Flushed is the total number of items handed to the flusher.
+		Flushed uint64
+		// Errors is the total number of batches that could not be written.
+		Errors uint64
+		// Sampled is the total number of items dropped by sampling, see
+		// WithSampleRate.
+		Sampled uint64
+		// Deduplicated is the total number of items dropped because their key
+		// was flushed within the dedup window, see WithDedupWindow.
+		Deduplicated uint64
+		// Coalesced is the total number of items superseded by a later item
+		// with the same key, see WithLastValuePerKey.
+		Coalesced uint64
+		// Cancelled is the total number of items dropped because their
+		// context was done, see WithSkipCancelled.
+		Cancelled uint64
+	}
+
+	statsRequest struct {
+		reply chan Stats
+		reset bool
+	}
+)
+
+// Stats returns a snapshot of the buffer's counters.
+//
+// The snapshot is taken by the consume goroutine itself, so it is consistent
+// and keeps the push path free of any synchronization, at the cost of waiting
+// for an in-progress flush to finish. It returns an ErrTimeout if the snapshot
+// cannot be taken within the flush timeout, an ErrNotInitialized if nothing has
+// been pushed yet, and an ErrClosed if the buffer has been closed.
+func (buffer *Buffer[T]) Stats() (Stats, error) {
+	return buffer.stats(false)
+}
+
+// ResetStats zeroes the cumulative counters and returns a snapshot of them taken
+// right before, so that reading and resetting them is atomic, which suits
+// reporting deltas. Pending and InFlight are not counters and are left alone, as
+// is everything else about the buffer. It fails like Stats.
+func (buffer *Buffer[T]) ResetStats() (Stats, error) {
+	return buffer.stats(true)
+}
+
+func (buffer *Buffer[T]) stats(reset bool) (Stats, error) {
+	if !buffer.IsIntialized() {
+		return Stats{}, ErrNotInitialized
+	}
+	if buffer.closed() {
+		return Stats{}, ErrClosed
+	}
+
+	reply := make(chan Stats, 1)
+	if buffer.Synchronous {
+		buffer.inline(func(c *consumer[T]) { c.snapshot(statsRequest{reply: reply, reset: reset}) })
+		return <-reply, nil
+	}
+	timeout := time.After(buffer.timeouts.flush.get(buffer.FlushTimeout))
+
+	select {
+	case buffer.statsCh <- statsRequest{reply: reply, reset: reset}:
+	case <-buffer.doneCh:
+		return Stats{}, ErrClosed
+	case <-timeout:
+		return Stats{}, buffer.timeoutError("stats", errors.New("failed to collect stats within flush timeout"))
+	}
+
+	return <-reply, nil
+}
+
+// Len returns the number of items currently buffered.
+//
+// Unlike Stats it never waits on the consume goroutine: it reads a counter the
+// consume goroutine keeps up to date, which includes a batch that is currently
+// being flushed.
+func (buffer *Buffer[T]) Len() int {
+	return int(buffer.pending.Load())
+}
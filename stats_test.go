@@ -0,0 +1,122 @@
+package buffer_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/omniboost/go-buffer"
+)
+
+var _ = Describe("Stats", func() {
+	var flusher *MockFlusher[any]
+
+	BeforeEach(func() {
+		flusher = NewMockFlusher[any]()
+	})
+
+	It("reports the buffer's counters", func() {
+		// arrange
+		sut := buffer.New[any]().
+			WithSize(2).
+			WithFlusher(flusher)
+
+		// act
+		err := sut.Push(1)
+		_ = sut.Push(2)
+		<-flusher.Done
+		_ = sut.Push(3)
+		stats, err1 := sut.Stats()
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(err1).To(Succeed())
+		Expect(stats).To(Equal(buffer.Stats{
+			Pending: 1,
+			Pushed:  3,
+			Flushes: 1,
+			Flushed: 2,
+		}))
+		Expect(sut.Len()).To(Equal(1))
+		_ = sut.Close()
+	})
+
+	It("counts batches that could not be written", func() {
+		// arrange
+		flusher.Err = errors.New("sink is down")
+		sut := buffer.New[any]().
+			WithSize(1).
+			WithFlusher(flusher)
+
+		// act
+		err := sut.Push(1)
+		<-flusher.Done
+		stats, err1 := sut.Stats()
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(err1).To(Succeed())
+		Expect(stats.Errors).To(Equal(uint64(1)))
+		_ = sut.Close()
+	})
+
+	It("resets the buffer's counters", func() {
+		// arrange
+		sut := buffer.New[any]().
+			WithSize(2).
+			WithFlusher(flusher)
+
+		err := sut.Push(1)
+		_ = sut.Push(2)
+		<-flusher.Done
+		_ = sut.Push(3)
+
+		// act
+		before, err1 := sut.ResetStats()
+		after, err2 := sut.Stats()
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(err1).To(Succeed())
+		Expect(err2).To(Succeed())
+		Expect(before).To(Equal(buffer.Stats{
+			Pending: 1,
+			Pushed:  3,
+			Flushes: 1,
+			Flushed: 2,
+		}))
+		Expect(after).To(Equal(buffer.Stats{Pending: 1}))
+		Expect(sut.Len()).To(Equal(1))
+		_ = sut.Close()
+	})
+
+	It("fails when the buffer is not initialized", func() {
+		// arrange
+		sut := buffer.New[any]().
+			WithSize(1).
+			WithFlusher(flusher)
+
+		// act
+		_, err := sut.Stats()
+
+		// assert
+		Expect(err).To(MatchError(buffer.ErrNotInitialized))
+	})
+
+	It("fails when the buffer is closed", func() {
+		// arrange
+		sut := buffer.New[any]().
+			WithSize(2).
+			WithFlusher(flusher)
+
+		_ = sut.Push(1)
+		_ = sut.Close()
+
+		// act
+		_, err := sut.Stats()
+
+		// assert
+		Expect(err).To(MatchError(buffer.ErrClosed))
+	})
+})
@@ -0,0 +1,10 @@
+package buffer
+
+// inline runs fn on the consumer of a buffer in synchronous mode, on the
+// calling goroutine, see WithSynchronousMode.
+func (buffer *Buffer[T]) inline(fn func(c *consumer[T])) {
+	buffer.directMu.Lock()
+	defer buffer.directMu.Unlock()
+
+	fn(buffer.direct)
+}
@@ -0,0 +1,116 @@
+package buffer_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/omniboost/go-buffer"
+)
+
+var _ = Describe("Synchronous mode", func() {
+	It("flushes on the calling goroutine", func() {
+		// arrange
+		flusher := buffer.NewCollectFlusher[int]()
+		sut := buffer.New[int]().
+			WithSize(2).
+			WithFlusher(flusher).
+			WithSynchronousMode()
+
+		// act
+		var err error
+		for i := 1; i <= 5; i++ {
+			err = sut.Push(i)
+		}
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(flusher.Batches()).To(Equal([][]int{{1, 2}, {3, 4}}))
+		Expect(sut.Len()).To(Equal(1))
+		Expect(sut.Flush()).To(Succeed())
+		Expect(flusher.Batches()).To(Equal([][]int{{1, 2}, {3, 4}, {5}}))
+	})
+
+	It("serves the other operations inline", func() {
+		// arrange
+		flusher := buffer.NewCollectFlusher[int]()
+		sut := buffer.New[int]().
+			WithSize(3).
+			WithFlusher(flusher).
+			WithSynchronousMode()
+
+		err := sut.Push(1)
+
+		// act
+		flushed, err1 := sut.PushX(2)
+		items, err2 := sut.FlushReturn(context.Background())
+		_ = sut.PushPriority(3)
+		_ = sut.Push(4)
+		stats, err3 := sut.Stats()
+		err4 := sut.Close()
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(err1).To(Succeed())
+		Expect(err2).To(Succeed())
+		Expect(err3).To(Succeed())
+		Expect(err4).To(Succeed())
+		Expect(flushed).To(BeFalse())
+		Expect(items).To(Equal([]int{1, 2}))
+		Expect(stats.Pushed).To(BeEquivalentTo(4))
+		Expect(stats.Flushes).To(BeEquivalentTo(2))
+		Expect(flusher.Batches()).To(Equal([][]int{{1, 2}, {3}, {4}}))
+		Expect(sut.Push(5)).To(MatchError(buffer.ErrClosed))
+	})
+
+	It("flushes on the calling goroutine when FlushContext is called", func() {
+		// arrange
+		flusher := buffer.NewCollectFlusher[int]()
+		sut := buffer.New[int]().
+			WithSize(3).
+			WithFlusher(flusher).
+			WithSynchronousMode()
+
+		err := sut.Push(1)
+
+		// act
+		err1 := sut.FlushContext(context.Background())
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(err1).To(Succeed())
+		Expect(flusher.Batches()).To(Equal([][]int{{1}}))
+		_ = sut.Close()
+	})
+
+	It("fails to combine with overlapping flushes", func() {
+		// arrange
+		sut := buffer.New[int]().
+			WithSize(2).
+			WithFlusher(buffer.NewCollectFlusher[int]()).
+			WithOverlappingFlush().
+			WithSynchronousMode()
+
+		// act
+		err := sut.Push(1)
+
+		// assert
+		Expect(err).To(MatchError(buffer.ErrInvalidSynchronousMode))
+	})
+
+	It("fails to combine with a channel buffer", func() {
+		// arrange
+		sut := buffer.New[int]().
+			WithSize(2).
+			WithFlusher(buffer.NewCollectFlusher[int]()).
+			WithChannelBuffer(4).
+			WithSynchronousMode()
+
+		// act
+		err := sut.Push(1)
+
+		// assert
+		Expect(err).To(MatchError(buffer.ErrInvalidSynchronousMode))
+	})
+})
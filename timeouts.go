@@ -0,0 +1,69 @@
+package buffer
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+type (
+	timeouts struct {
+		push  liveDuration
+		flush liveDuration
+		close liveDuration
+	}
+
+	// liveDuration holds a duration that can be changed while the buffer is in
+	// use, falling back to the configured option until it is first set.
+	liveDuration struct {
+		value atomic.Pointer[time.Duration]
+	}
+)
+
+// SetPushTimeout changes how long a push should wait before giving up. It is
+// safe to call while the buffer is in use.
+func (buffer *Buffer[T]) SetPushTimeout(timeout time.Duration) error {
+	return buffer.timeouts.push.set(timeout, "PushTimeout")
+}
+
+// SetFlushTimeout changes how long a manual flush should wait before giving up.
+// It is safe to call while the buffer is in use.
+func (buffer *Buffer[T]) SetFlushTimeout(timeout time.Duration) error {
+	return buffer.timeouts.flush.set(timeout, "FlushTimeout")
+}
+
+// SetCloseTimeout changes how long a close should wait before giving up. It is
+// safe to call while the buffer is in use.
+func (buffer *Buffer[T]) SetCloseTimeout(timeout time.Duration) error {
+	return buffer.timeouts.close.set(timeout, "CloseTimeout")
+}
+
+func (buffer *Buffer[T]) pushTimeout() time.Duration {
+	return buffer.timeouts.push.get(buffer.PushTimeout)
+}
+
+func (buffer *Buffer[T]) closeTimeout() time.Duration {
+	return buffer.timeouts.close.get(buffer.CloseTimeout)
+}
+
+// flushTimeout returns the flush timeout scaled by the number of pending items.
+func (buffer *Buffer[T]) flushTimeout() time.Duration {
+	base := buffer.timeouts.flush.get(buffer.FlushTimeout)
+	return base + time.Duration(buffer.pending.Load())*buffer.PerItemFlushTimeout
+}
+
+func (d *liveDuration) get(fallback time.Duration) time.Duration {
+	if value := d.value.Load(); value != nil {
+		return *value
+	}
+
+	return fallback
+}
+
+func (d *liveDuration) set(value time.Duration, field string) error {
+	if value < 0 {
+		return invalidField(ErrInvalidTimeout, field)
+	}
+
+	d.value.Store(&value)
+	return nil
+}
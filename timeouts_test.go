@@ -0,0 +1,86 @@
+package buffer_test
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/omniboost/go-buffer"
+)
+
+var _ = Describe("Timeouts", func() {
+	var flusher *MockFlusher[any]
+
+	BeforeEach(func() {
+		flusher = NewMockFlusher[any]()
+	})
+
+	It("applies a push timeout changed at runtime", func() {
+		// arrange
+		flusher.Func = func() { time.Sleep(200 * time.Millisecond) }
+		sut := buffer.New[any]().
+			WithSize(1).
+			WithFlusher(flusher).
+			WithPushTimeout(time.Minute)
+
+		err := sut.Push(1)
+
+		// act
+		err1 := sut.SetPushTimeout(10 * time.Millisecond)
+		start := time.Now()
+		err2 := sut.Push(2)
+
+		// assert
+		Expect(err).To(Succeed())
+		Expect(err1).To(Succeed())
+		Expect(err2).To(MatchError(buffer.ErrTimeout))
+		Expect(time.Since(start)).To(BeNumerically("<", 100*time.Millisecond))
+	})
+
+	It("rejects negative timeouts", func() {
+		// arrange
+		sut := buffer.New[any]()
+
+		// act
+		err1 := sut.SetPushTimeout(-1)
+		err2 := sut.SetFlushTimeout(-1)
+		err3 := sut.SetCloseTimeout(-1)
+
+		// assert
+		Expect(err1).To(MatchError(buffer.ErrInvalidTimeout))
+		Expect(err2).To(MatchError(buffer.ErrInvalidTimeout))
+		Expect(err3).To(MatchError(buffer.ErrInvalidTimeout))
+	})
+
+	It("allows timeouts to change while the buffer is in use", func() {
+		// arrange
+		sut := buffer.New[any]().
+			WithSize(10).
+			WithFlusher(buffer.FlusherFunc[any](func([]any) error { return nil }))
+
+		// act
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				_ = sut.Push(i)
+				_ = sut.Flush()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				_ = sut.SetPushTimeout(time.Duration(i+1) * 10 * time.Millisecond)
+				_ = sut.SetFlushTimeout(time.Duration(i+1) * 10 * time.Millisecond)
+				_ = sut.SetCloseTimeout(time.Duration(i+1) * 10 * time.Millisecond)
+			}
+		}()
+		wg.Wait()
+
+		// assert
+		Expect(sut.Close()).To(Succeed())
+	})
+})
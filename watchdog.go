@@ -0,0 +1,50 @@
+package buffer
+
+import (
+	"sync"
+	"time"
+)
+
+// watchdog calls onStuck whenever no flush has completed for a whole period
+// while the buffer holds items, see WithWatchdog.
+type watchdog struct {
+	mu      sync.Mutex
+	timer   *time.Timer
+	period  time.Duration
+	stopped bool
+}
+
+// start arms the watchdog, checking pending for the number of buffered items.
+func (w *watchdog) start(period time.Duration, pending func() int, onStuck func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.period = period
+	w.timer = time.AfterFunc(period, func() {
+		if pending() > 0 {
+			onStuck()
+		}
+		w.kick()
+	})
+}
+
+// kick restarts the period, it is called after every completed flush and when
+// the buffer stops being empty.
+func (w *watchdog) kick() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil && !w.stopped {
+		w.timer.Reset(w.period)
+	}
+}
+
+func (w *watchdog) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.stopped = true
+		w.timer.Stop()
+	}
+}
@@ -0,0 +1,58 @@
+package buffer_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/omniboost/go-buffer"
+)
+
+var _ = Describe("Watchdog", func() {
+	It("reports a buffer whose flusher is stuck", func() {
+		// arrange
+		release := make(chan struct{})
+		stuck := make(chan struct{}, 1)
+		sut := buffer.New[int]().
+			WithSize(1).
+			WithFlusher(buffer.FlusherFunc[int](func([]int) error {
+				<-release
+				return nil
+			})).
+			WithPushTimeout(time.Second).
+			WithWatchdog(50*time.Millisecond, func() {
+				select {
+				case stuck <- struct{}{}:
+				default:
+				}
+			})
+
+		// act
+		err := sut.Push(1)
+		_ = sut.Push(2)
+
+		// assert
+		Expect(err).To(Succeed())
+		Eventually(stuck).Should(Receive())
+		close(release)
+		_ = sut.Close()
+	})
+
+	It("leaves an idle buffer alone", func() {
+		// arrange
+		stuck := make(chan struct{}, 1)
+		sut := buffer.New[int]().
+			WithSize(2).
+			WithFlusher(buffer.FlusherFunc[int](func([]int) error { return nil })).
+			WithWatchdog(50*time.Millisecond, func() { stuck <- struct{}{} })
+
+		// act
+		err := sut.Start()
+
+		// assert
+		Expect(err).To(Succeed())
+		Consistently(stuck, 200*time.Millisecond).ShouldNot(Receive())
+		_ = sut.Close()
+	})
+})
@@ -0,0 +1,164 @@
+package buffer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// ByteBuffer is a buffer of byte slices that implements io.Writer, every Write
+// pushing a copy of its bytes, so that code writing to a writer can batch its
+// writes. Any buffer of byte slices can be wrapped:
+//
+//	w := &buffer.ByteBuffer{Buffer: b}
+type ByteBuffer struct {
+	*Buffer[[]byte]
+}
+
+// NewWriterBuffer creates a buffer that batches writes to w through a
+// WriterFlusher, flushing once it holds size writes or, if interval is not zero,
+// once interval has elapsed. The usual options can still be set on the embedded
+// buffer before the first Write.
+func NewWriterBuffer(w io.Writer, size uint, interval time.Duration) *ByteBuffer {
+	return &ByteBuffer{
+		Buffer: New[[]byte]().
+			WithSize(size).
+			WithFlusher(WriterFlusher(w)).
+			WithFlushInterval(interval),
+	}
+}
+
+// Write pushes a copy of p to the buffer, as callers such as fmt.Fprintf reuse
+// it. An empty p is not pushed. Writing to a closed buffer fails with an
+// io.ErrClosedPipe, other push errors are returned as is.
+func (w *ByteBuffer) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := w.Push(bytes.Clone(p)); err != nil {
+		if errors.Is(err, ErrClosed) {
+			return 0, io.ErrClosedPipe
+		}
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// WriterFlusher creates a flusher that concatenates the items of a batch and
+// writes them to w in a single call. An empty batch is not written at all.
+func WriterFlusher(w io.Writer) Flusher[[]byte] {
+	return FlusherFunc[[]byte](func(items [][]byte) error {
+		if len(items) == 0 {
+			return nil
+		}
+
+		_, err := w.Write(bytes.Join(items, nil))
+		return err
+	})
+}
+
+// SyncWriterFlusher creates a flusher that writes every item of a batch to f
+// through a buffered writer of bufSize bytes, then flushes the buffered writer
+// and syncs f to stable storage, so a batch survives a crash once Write has
+// returned successfully. After a failed Write, whatever was left in the buffered
+// writer is discarded, so that a retry starts afresh.
+//
+// The buffered writer is shared by every batch, so the flusher must not be
+// used with WithOverlappingFlush.
+func SyncWriterFlusher(f *os.File, bufSize int) Flusher[[]byte] {
+	w := bufio.NewWriterSize(f, bufSize)
+
+	write := func(items [][]byte) error {
+		for _, item := range items {
+			if _, err := w.Write(item); err != nil {
+				return err
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		return f.Sync()
+	}
+
+	return FlusherFunc[[]byte](func(items [][]byte) error {
+		err := write(items)
+		if err != nil {
+			// a bufio.Writer keeps failing once a write failed
+			w.Reset(f)
+		}
+
+		return err
+	})
+}
+
+// GzipFlusher creates a flusher that concatenates the items of a batch,
+// compresses them with gzip at the given level, and forwards the result to next
+// as a batch of a single item. An empty batch is not forwarded at all. An
+// invalid level makes every Write fail.
+func GzipFlusher(next Flusher[[]byte], level int) Flusher[[]byte] {
+	return FlusherFunc[[]byte](func(items [][]byte) error {
+		if len(items) == 0 {
+			return nil
+		}
+
+		var compressed bytes.Buffer
+		w, err := gzip.NewWriterLevel(&compressed, level)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if _, err := w.Write(item); err != nil {
+				return err
+			}
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+
+		return next.Write([][]byte{compressed.Bytes()})
+	})
+}
+
+// JSONLinesFlusher creates a flusher that marshals every item of a batch to JSON
+// and writes the lines, each followed by a newline, to w in a single call.
+//
+// Items that cannot be marshalled are skipped rather than failing the whole
+// batch, and are passed to onError along with the marshalling errors, for
+// instance the buffer's error handler. Errors writing to w are returned.
+func JSONLinesFlusher[T any](w io.Writer, onError func(err error, items []T)) Flusher[T] {
+	return FlusherFunc[T](func(items []T) error {
+		var (
+			lines   bytes.Buffer
+			errs    error
+			skipped []T
+		)
+
+		for _, item := range items {
+			line, err := json.Marshal(item)
+			if err != nil {
+				errs = errors.Join(errs, err)
+				skipped = append(skipped, item)
+				continue
+			}
+			lines.Write(line)
+			lines.WriteByte('\n')
+		}
+
+		if len(skipped) > 0 && onError != nil {
+			onError(errs, skipped)
+		}
+		if lines.Len() == 0 {
+			return nil
+		}
+
+		_, err := w.Write(lines.Bytes())
+		return err
+	})
+}
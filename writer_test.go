@@ -0,0 +1,276 @@
+package buffer_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/omniboost/go-buffer"
+)
+
+var _ = Describe("Writers", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "go-buffer")
+		Expect(err).To(Succeed())
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(dir)
+	})
+
+	Context("SyncWriterFlusher", func() {
+		It("writes every batch through to the file", func() {
+			// arrange
+			f, err := os.Create(filepath.Join(dir, "out"))
+			Expect(err).To(Succeed())
+			defer f.Close()
+
+			sut := buffer.SyncWriterFlusher(f, 4)
+
+			// act
+			err1 := sut.Write([][]byte{[]byte("hello\n"), []byte("world\n")})
+
+			// assert
+			Expect(err1).To(Succeed())
+			Expect(os.ReadFile(f.Name())).To(Equal([]byte("hello\nworld\n")))
+		})
+
+		It("returns write errors", func() {
+			// arrange
+			f, err := os.Create(filepath.Join(dir, "out"))
+			Expect(err).To(Succeed())
+			_ = f.Close()
+
+			sut := buffer.SyncWriterFlusher(f, 4)
+
+			// act
+			err1 := sut.Write([][]byte{[]byte("hello\n")})
+
+			// assert
+			Expect(err1).To(MatchError(os.ErrClosed))
+		})
+
+		It("recovers from a failed write", func() {
+			// arrange
+			// a pipe cannot be synced, but its writes can fail transiently
+			r, w, err := os.Pipe()
+			Expect(err).To(Succeed())
+			defer r.Close()
+			defer w.Close()
+
+			sut := buffer.SyncWriterFlusher(w, 4)
+			_ = w.SetWriteDeadline(time.Now().Add(-time.Second))
+			err1 := sut.Write([][]byte{[]byte("hello\n")})
+			_ = w.SetWriteDeadline(time.Time{})
+
+			// act
+			err2 := sut.Write([][]byte{[]byte("world\n")})
+
+			// assert
+			Expect(err1).To(MatchError(os.ErrDeadlineExceeded))
+			Expect(err2).NotTo(MatchError(os.ErrDeadlineExceeded))
+			p := make([]byte, 16)
+			n, _ := r.Read(p)
+			Expect(string(p[:n])).To(Equal("world\n"))
+		})
+	})
+
+	Context("GzipFlusher", func() {
+		It("forwards the compressed batch as a single item", func() {
+			// arrange
+			ch := make(chan [][]byte, 1)
+			sut := buffer.GzipFlusher(buffer.NewChannelFlusher[[]byte](ch, 0), gzip.BestSpeed)
+
+			// act
+			err := sut.Write([][]byte{[]byte("hello\n"), []byte("world\n")})
+
+			// assert
+			Expect(err).To(Succeed())
+			var batch [][]byte
+			Expect(ch).To(Receive(&batch))
+			Expect(batch).To(HaveLen(1))
+			r, err1 := gzip.NewReader(bytes.NewReader(batch[0]))
+			Expect(err1).To(Succeed())
+			Expect(io.ReadAll(r)).To(Equal([]byte("hello\nworld\n")))
+		})
+
+		It("does not forward an empty batch", func() {
+			// arrange
+			ch := make(chan [][]byte, 1)
+			sut := buffer.GzipFlusher(buffer.NewChannelFlusher[[]byte](ch, 0), gzip.BestSpeed)
+
+			// act
+			err := sut.Write(nil)
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(ch).NotTo(Receive())
+		})
+
+		It("propagates errors", func() {
+			// arrange
+			next := buffer.FlusherFunc[[]byte](func([][]byte) error { return errors.New("sink is down") })
+
+			// act
+			err := buffer.GzipFlusher(next, gzip.BestSpeed).Write([][]byte{[]byte("a")})
+			err1 := buffer.GzipFlusher(next, 42).Write([][]byte{[]byte("a")})
+
+			// assert
+			Expect(err).To(MatchError("sink is down"))
+			Expect(err1).To(HaveOccurred())
+		})
+	})
+
+	Context("JSONLinesFlusher", func() {
+		It("writes every item as a line of JSON", func() {
+			// arrange
+			var out bytes.Buffer
+			sut := buffer.JSONLinesFlusher[map[string]int](&out, nil)
+
+			// act
+			err := sut.Write([]map[string]int{{"a": 1}, {"b": 2}})
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(out.String()).To(Equal("{\"a\":1}\n{\"b\":2}\n"))
+		})
+
+		It("skips items that cannot be marshalled", func() {
+			// arrange
+			var (
+				out     bytes.Buffer
+				skipped []any
+				failure error
+			)
+			sut := buffer.JSONLinesFlusher[any](&out, func(err error, items []any) {
+				failure, skipped = err, items
+			})
+
+			// act
+			err := sut.Write([]any{1, make(chan int), 2})
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(out.String()).To(Equal("1\n2\n"))
+			Expect(failure).To(HaveOccurred())
+			Expect(skipped).To(HaveLen(1))
+		})
+
+		It("returns write errors", func() {
+			// arrange
+			f, err := os.Create(filepath.Join(dir, "out"))
+			Expect(err).To(Succeed())
+			_ = f.Close()
+
+			sut := buffer.JSONLinesFlusher[int](f, nil)
+
+			// act
+			err1 := sut.Write([]int{1})
+
+			// assert
+			Expect(err1).To(MatchError(os.ErrClosed))
+		})
+	})
+
+	Context("WriterFlusher", func() {
+		It("writes a batch to the writer in a single call", func() {
+			// arrange
+			var out bytes.Buffer
+			sut := buffer.WriterFlusher(&out)
+
+			// act
+			err := sut.Write([][]byte{[]byte("hello "), []byte("world")})
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(out.String()).To(Equal("hello world"))
+		})
+	})
+
+	Context("NewWriterBuffer", func() {
+		It("pushes every write to the buffer", func() {
+			// arrange
+			var out bytes.Buffer
+			sut := buffer.NewWriterBuffer(&out, 3, 0)
+
+			// act
+			_, err := fmt.Fprintf(sut, "a=%d\n", 1)
+			_, err1 := fmt.Fprintf(sut, "b=%d\n", 2)
+			err2 := sut.Close()
+
+			// assert
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Expect(err2).To(Succeed())
+			Expect(out.String()).To(Equal("a=1\nb=2\n"))
+		})
+
+		It("fails writes to a closed buffer", func() {
+			// arrange
+			var out bytes.Buffer
+			sut := buffer.NewWriterBuffer(&out, 3, 0)
+			_ = sut.Start()
+			_ = sut.Close()
+
+			// act
+			n, err := sut.Write([]byte("late"))
+
+			// assert
+			Expect(n).To(BeZero())
+			Expect(err).To(MatchError(io.ErrClosedPipe))
+		})
+	})
+
+	Context("ByteBuffer", func() {
+		It("copies the bytes of every write", func() {
+			// arrange
+			batches := make(chan [][]byte, 1)
+			sut := &buffer.ByteBuffer{
+				Buffer: buffer.New[[]byte]().
+					WithSize(2).
+					WithFlusher(buffer.NewChannelFlusher[[]byte](batches, 0)),
+			}
+			p := []byte("one")
+
+			// act
+			n, err := sut.Write(p)
+			copy(p, "two")
+			_, err1 := sut.Write(p)
+
+			// assert
+			Expect(n).To(Equal(3))
+			Expect(err).To(Succeed())
+			Expect(err1).To(Succeed())
+			Eventually(batches).Should(Receive(Equal([][]byte{[]byte("one"), []byte("two")})))
+			_ = sut.Close()
+		})
+
+		It("ignores empty writes", func() {
+			// arrange
+			sut := &buffer.ByteBuffer{
+				Buffer: buffer.New[[]byte]().
+					WithSize(2).
+					WithFlusher(buffer.FlusherFunc[[]byte](func([][]byte) error { return nil })),
+			}
+
+			// act
+			n, err := sut.Write(nil)
+
+			// assert
+			Expect(n).To(BeZero())
+			Expect(err).To(Succeed())
+			Expect(sut.IsIntialized()).To(BeFalse())
+		})
+	})
+})